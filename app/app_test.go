@@ -2,10 +2,13 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -37,6 +40,23 @@ func TestWithHealthOK(t *testing.T) {
 	}
 }
 
+func TestWithHealthHead(t *testing.T) {
+	h := WithHealth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodHead, "/healthz", nil)
+
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ожидали статус %d, получили %d", http.StatusOK, rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Fatalf("ожидали пустое тело, получили %q", rec.Body.String())
+	}
+}
+
 func TestWithHealthPassThrough(t *testing.T) {
 	called := false
 	base := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -163,3 +183,295 @@ func TestRunNilHandler(t *testing.T) {
 		t.Fatalf("ожидали ErrNilHandler, получили %v", err)
 	}
 }
+
+func runWorkersApp(t *testing.T, ctx context.Context, workers ...Worker) <-chan error {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 100 * time.Millisecond
+	cfg.Workers = workers
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, WithHealth(http.NotFoundHandler()), ln)
+	}()
+	return done
+}
+
+func waitRun(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatalf("таймаут ожидания завершения Run")
+		return nil
+	}
+}
+
+func TestWorkersCancelledOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	done := runWorkersApp(t, ctx, WorkerFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	}))
+
+	<-started
+	cancel()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Fatalf("worker должен завершиться до возврата Run")
+	}
+}
+
+func TestWorkerErrorStopsApp(t *testing.T) {
+	boom := errors.New("boom")
+	cancelled := make(chan struct{})
+	done := runWorkersApp(t, context.Background(),
+		WorkerFunc(func(ctx context.Context) error {
+			return boom
+		}),
+		WorkerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			close(cancelled)
+			return nil
+		}),
+	)
+
+	if err := waitRun(t, done); !errors.Is(err, boom) {
+		t.Fatalf("ожидали ошибку worker, получили %v", err)
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Fatalf("остальные workers должны быть отменены")
+	}
+}
+
+func TestWorkersShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	done := runWorkersApp(t, ctx, WorkerFunc(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}))
+
+	<-started
+	cancel()
+	if err := waitRun(t, done); !errors.Is(err, ErrWorkersTimeout) {
+		t.Fatalf("ожидали ErrWorkersTimeout, получили %v", err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(EnvAddr, ":9090")
+	t.Setenv(EnvShutdownTimeout, "30s")
+
+	cfg, err := ConfigFromEnv(DefaultConfig())
+	if err != nil {
+		t.Fatalf("не ожидали ошибку: %v", err)
+	}
+	if cfg.Addr != ":9090" || cfg.ShutdownTimeout != 30*time.Second {
+		t.Fatalf("окружение не применено: %+v", cfg)
+	}
+	if cfg.ReadTimeout != DefaultConfig().ReadTimeout {
+		t.Fatalf("пустые переменные должны сохранять дефолты: %+v", cfg)
+	}
+
+	t.Setenv(EnvReadTimeout, "soon")
+	if _, err := ConfigFromEnv(DefaultConfig()); err == nil || !strings.Contains(err.Error(), EnvReadTimeout) {
+		t.Fatalf("ожидали ошибку %s, получили %v", EnvReadTimeout, err)
+	}
+}
+
+func TestReadinessReport(t *testing.T) {
+	ready := NewReadiness()
+	ready.Add("db", func(ctx context.Context) error { return nil }, 0)
+	ready.Add("upstream", func(ctx context.Context) error { return errors.New("secret details") }, 0)
+	ready.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 20*time.Millisecond)
+
+	h := WithReadiness(http.NotFoundHandler(), ready)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("ожидали 503, получили %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Fatalf("текст ошибки не должен попадать в отчёт: %s", w.Body.String())
+	}
+	var report ReadinessReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("не удалось распарсить JSON: %v", err)
+	}
+	want := map[string]string{"db": ReadyStatusOK, "upstream": ReadyStatusFail, "slow": ReadyStatusTimeout}
+	for name, status := range want {
+		if report.Checks[name].Status != status {
+			t.Fatalf("check %s: ожидали %s, получили %+v", name, status, report.Checks[name])
+		}
+	}
+}
+
+func TestReadinessDrain(t *testing.T) {
+	ready := NewReadiness()
+	h := WithReadiness(http.NotFoundHandler(), ready)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ожидали 200, получили %d", w.Code)
+	}
+
+	ready.Drain()
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), ReadyStatusDraining) {
+		t.Fatalf("ожидали 503 draining, получили %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRunDrainsBeforeShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ready := NewReadiness()
+	cfg := DefaultConfig()
+	cfg.Readiness = ready
+	cfg.DrainTimeout = 300 * time.Millisecond
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, WithReadiness(WithHealth(http.NotFoundHandler()), ready), ln)
+	}()
+	addr := ln.Addr().String()
+	if err := waitForHealth(addr, 2*time.Second); err != nil {
+		t.Fatalf("не дождались /healthz: %v", err)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for !ready.Draining() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	r, err := http.Get("http://" + addr + "/readyz")
+	if err != nil {
+		t.Fatalf("сервер должен принимать запросы во время drain: %v", err)
+	}
+	_ = r.Body.Close()
+	if r.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("ожидали 503 во время drain, получили %d", r.StatusCode)
+	}
+
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+}
+
+func TestRunMultipleListeners(t *testing.T) {
+	public, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	admin := probe.Addr().String()
+	_ = probe.Close()
+
+	var adminSeen atomic.Int32
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+	cfg.Listeners = []Listener{{
+		Name:    "admin",
+		Addr:    admin,
+		Handler: WithPprof(WithHealth(http.NotFoundHandler())),
+		Hooks: Hooks{OnRequestEnd: func(ctx context.Context, info RequestInfo, res ResponseInfo) {
+			adminSeen.Add(1)
+		}},
+	}}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, WithHealth(http.NotFoundHandler()), public)
+	}()
+
+	if err := waitForHealth(public.Addr().String(), 2*time.Second); err != nil {
+		t.Fatalf("не дождались публичного /healthz: %v", err)
+	}
+	if err := waitForHealth(admin, 2*time.Second); err != nil {
+		t.Fatalf("не дождались admin /healthz: %v", err)
+	}
+	if adminSeen.Load() == 0 {
+		t.Fatalf("hooks admin listener не вызваны")
+	}
+
+	for addr, want := range map[string]int{public.Addr().String(): http.StatusNotFound, admin: http.StatusOK} {
+		r, err := http.Get("http://" + addr + "/debug/pprof/")
+		if err != nil {
+			t.Fatalf("запрос к %s: %v", addr, err)
+		}
+		_ = r.Body.Close()
+		if r.StatusCode != want {
+			t.Fatalf("%s: ожидали %d, получили %d", addr, want, r.StatusCode)
+		}
+	}
+
+	cancel()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+	for _, addr := range []string{public.Addr().String(), admin} {
+		if c, err := net.DialTimeout("tcp", addr, 100*time.Millisecond); err == nil {
+			_ = c.Close()
+			t.Fatalf("listener %s должен быть закрыт", addr)
+		}
+	}
+}
+
+func TestRunListenerErrorNamesListener(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	defer busy.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Listeners = []Listener{{Name: "admin", Addr: busy.Addr().String(), Handler: http.NotFoundHandler()}}
+	err = runWithListener(context.Background(), cfg, http.NotFoundHandler(), ln)
+	if err == nil || !strings.Contains(err.Error(), "listener admin") {
+		t.Fatalf("ожидали ошибку admin listener, получили %v", err)
+	}
+}
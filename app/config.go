@@ -1,16 +1,37 @@
 package app
 
-import "time"
+import (
+	"net/http"
+	"os"
+	"time"
+)
 
 // Config описывает параметры HTTP-сервера.
 type Config struct {
-	Addr              string // например ":8080"
+	Addr              string // ":8080", "unix:/run/app.sock" или "systemd:http"
 	ReadTimeout       time.Duration
 	ReadHeaderTimeout time.Duration
 	WriteTimeout      time.Duration
 	IdleTimeout       time.Duration
 	ShutdownTimeout   time.Duration // таймаут graceful shutdown
 	Hooks             Hooks
+	Workers           []Worker             // фоновые задачи, см. Worker
+	Readiness         *Readiness           // переводится в drain при остановке
+	DrainTimeout      time.Duration        // пауза между drain и Shutdown; 0 — без паузы
+	TLS               *TLSConfig           // nil — plaintext HTTP
+	Listeners         []Listener           // дополнительные listeners, например admin‑порт
+	SocketMode        os.FileMode          // права Unix sockets; 0 — DefaultSocketMode
+	Upgrade           *UpgradeConfig       // обновление бинаря без простоя; nil — выключено
+	ProxyProtocol     *ProxyProtocolConfig // PROXY protocol на основном listener; nil — выключено
+	MaxConns          int                  // одновременных соединений на listener; 0 — без лимита
+	MaxConnsPerIP     int                  // одновременных соединений с одного IP клиента (после PROXY protocol); 0 — без лимита
+	MaxHeaderBytes    int                  // лимит заголовков запроса; 0 — http.DefaultMaxHeaderBytes
+	HTTP2             *http.HTTP2Config    // настройки HTTP/2 (MaxConcurrentStreams и др.)
+	ConnGauge         ConnGauge            // число соединений по состояниям (ConnState)
+	// H2C включает HTTP/2 без TLS на основном listener рядом с HTTP/1.1, например для
+	// Envoy/service mesh: с prior knowledge и через Upgrade: h2c (для запросов без тела;
+	// запросы с телом остаются на HTTP/1.1). Несовместимо с TLS.
+	H2C bool
 }
 
 // DefaultConfig возвращает безопасные дефолты.
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Переменные окружения, которые читает ConfigFromEnv.
+const (
+	EnvAddr              = "NOPE_ADDR"
+	EnvReadTimeout       = "NOPE_READ_TIMEOUT"
+	EnvReadHeaderTimeout = "NOPE_READ_HEADER_TIMEOUT"
+	EnvWriteTimeout      = "NOPE_WRITE_TIMEOUT"
+	EnvIdleTimeout       = "NOPE_IDLE_TIMEOUT"
+	EnvShutdownTimeout   = "NOPE_SHUTDOWN_TIMEOUT"
+	EnvDrainTimeout      = "NOPE_DRAIN_TIMEOUT"
+	EnvTLSCertFile       = "NOPE_TLS_CERT_FILE"
+	EnvTLSKeyFile        = "NOPE_TLS_KEY_FILE"
+	EnvTLSClientCAFile   = "NOPE_TLS_CLIENT_CA_FILE"
+)
+
+// ConfigFromEnv дополняет cfg значениями из окружения.
+// Пустые переменные не меняют cfg; длительности задаются в формате time.ParseDuration ("15s").
+func ConfigFromEnv(cfg Config) (Config, error) {
+	if v := os.Getenv(EnvAddr); v != "" {
+		cfg.Addr = v
+	}
+	durations := []struct {
+		key string
+		dst *time.Duration
+	}{
+		{EnvReadTimeout, &cfg.ReadTimeout},
+		{EnvReadHeaderTimeout, &cfg.ReadHeaderTimeout},
+		{EnvWriteTimeout, &cfg.WriteTimeout},
+		{EnvIdleTimeout, &cfg.IdleTimeout},
+		{EnvShutdownTimeout, &cfg.ShutdownTimeout},
+		{EnvDrainTimeout, &cfg.DrainTimeout},
+	}
+	for _, d := range durations {
+		if err := EnvDuration(d.key, d.dst); err != nil {
+			return cfg, err
+		}
+	}
+	cert, key := os.Getenv(EnvTLSCertFile), os.Getenv(EnvTLSKeyFile)
+	if cert != "" || key != "" {
+		if cert == "" || key == "" {
+			return cfg, fmt.Errorf("app: %s and %s must be set together", EnvTLSCertFile, EnvTLSKeyFile)
+		}
+		tlsCfg := TLSConfig{}
+		if cfg.TLS != nil {
+			tlsCfg = *cfg.TLS
+		}
+		tlsCfg.CertFile, tlsCfg.KeyFile = cert, key
+		if ca := os.Getenv(EnvTLSClientCAFile); ca != "" {
+			tlsCfg.ClientCAFile = ca
+		}
+		cfg.TLS = &tlsCfg
+	}
+	return cfg, nil
+}
+
+// EnvDuration читает длительность из переменной key в dst; пустая переменная не меняет dst.
+func EnvDuration(key string, dst *time.Duration) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return fmt.Errorf("app: invalid %s=%q: expected duration like 5s", key, v)
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,298 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Переход с HTTP/1.1 на h2c (RFC 7540, 3.2). net/http обслуживает HTTP/2 без TLS только
+// с prior knowledge, поэтому после 101 Switching Protocols соединение возвращается серверу
+// через h2cListener: после клиентского preface идёт SETTINGS с параметрами из HTTP2-Settings
+// и следом параметрами первого SETTINGS клиента (одним кадром, чтобы сервер прислал
+// единственный ACK, которого ждёт клиент), а исходный запрос подставляется кадром HEADERS
+// потока 1 — на него сервер и отвечает по HTTP/2.
+
+const (
+	h2cPreface        = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+	h2cPrefaceTimeout = 10 * time.Second
+	h2cMaxFrameSize   = 16384
+
+	h2FrameHeaders      = 0x1
+	h2FrameSettings     = 0x4
+	h2FrameContinuation = 0x9
+
+	h2FlagEndStream  = 0x1
+	h2FlagAck        = 0x1
+	h2FlagEndHeaders = 0x4
+
+	h2SettingSize = 6
+)
+
+var errH2CPreface = errors.New("app: invalid h2c client preface")
+
+// h2cHopHeaders не передаются в HTTP/2 (RFC 7540, 8.1.2.2).
+var h2cHopHeaders = map[string]bool{
+	"Connection":        true,
+	"Http2-Settings":    true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Host":              true,
+}
+
+// h2cListener выдаёт серверу принятые соединения и соединения после Upgrade: h2c.
+type h2cListener struct {
+	net.Listener
+	accepted chan acceptResult
+	upgraded chan net.Conn
+	done     chan struct{}
+	once     sync.Once
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+func newH2CListener(ln net.Listener) *h2cListener {
+	l := &h2cListener{
+		Listener: ln,
+		accepted: make(chan acceptResult),
+		upgraded: make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+	go l.acceptLoop()
+	return l
+}
+
+func (l *h2cListener) acceptLoop() {
+	for {
+		c, err := l.Listener.Accept()
+		select {
+		case l.accepted <- acceptResult{conn: c, err: err}:
+		case <-l.done:
+			if c != nil {
+				_ = c.Close()
+			}
+			return
+		}
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+	}
+}
+
+func (l *h2cListener) Accept() (net.Conn, error) {
+	select {
+	case r := <-l.accepted:
+		return r.conn, r.err
+	case c := <-l.upgraded:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *h2cListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+// serveConn возвращает соединение серверу; после Close оно закрывается.
+func (l *h2cListener) serveConn(c net.Conn) {
+	select {
+	case l.upgraded <- c:
+	case <-l.done:
+		_ = c.Close()
+	}
+}
+
+// h2cUpgrade переводит на HTTP/2 запросы с Upgrade: h2c и без тела.
+// Запросы с телом обслуживаются по HTTP/1.1 — RFC разрешает не переходить.
+func h2cUpgrade(next http.Handler, l *h2cListener, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		timeout = h2cPrefaceTimeout
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings, ok := h2cSettings(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		c, err := switchToH2C(conn, brw, r, settings, timeout)
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+		l.serveConn(c)
+	})
+}
+
+// h2cSettings проверяет, что запрос просит переход на h2c, и возвращает полезную нагрузку
+// SETTINGS из заголовка HTTP2-Settings (RFC 7540, 3.2.1).
+func h2cSettings(r *http.Request) ([]byte, bool) {
+	if r.ProtoMajor != 1 || r.ContentLength != 0 || len(r.TransferEncoding) > 0 {
+		return nil, false
+	}
+	if !headerHasToken(r.Header, "Upgrade", "h2c") ||
+		!headerHasToken(r.Header, "Connection", "upgrade") ||
+		!headerHasToken(r.Header, "Connection", "http2-settings") {
+		return nil, false
+	}
+	values := r.Header.Values("Http2-Settings")
+	if len(values) != 1 {
+		return nil, false
+	}
+	settings, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(values[0], "="))
+	if err != nil || len(settings)%h2SettingSize != 0 || len(settings) > h2cMaxFrameSize {
+		return nil, false
+	}
+	return settings, true
+}
+
+func headerHasToken(h http.Header, key, token string) bool {
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// switchToH2C отвечает 101, читает preface и SETTINGS клиента и возвращает соединение,
+// которое начинается с preface, SETTINGS (settings из заголовка, затем клиентские)
+// и кадра HEADERS исходного запроса.
+func switchToH2C(conn net.Conn, brw *bufio.ReadWriter, r *http.Request, settings []byte, timeout time.Duration) (net.Conn, error) {
+	if _, err := io.WriteString(brw, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n"); err != nil {
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	preface := make([]byte, len(h2cPreface)+9)
+	if _, err := io.ReadFull(brw, preface); err != nil {
+		return nil, err
+	}
+	hdr := preface[len(h2cPreface):]
+	if string(preface[:len(h2cPreface)]) != h2cPreface || hdr[3] != h2FrameSettings ||
+		hdr[4]&h2FlagAck != 0 || binary.BigEndian.Uint32(hdr[5:9]) != 0 {
+		return nil, errH2CPreface
+	}
+	size := int(hdr[0])<<16 | int(hdr[1])<<8 | int(hdr[2])
+	if size%h2SettingSize != 0 || len(settings)+size > h2cMaxFrameSize {
+		return nil, errH2CPreface
+	}
+	payload := make([]byte, len(settings)+size)
+	copy(payload, settings)
+	if _, err := io.ReadFull(brw, payload[len(settings):]); err != nil {
+		return nil, err
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	prefix := appendFrame([]byte(h2cPreface), h2FrameSettings, 0, 0, payload)
+	prefix = appendHeaderFrames(prefix, 1, h2cRequestFields(r))
+	return &h2cConn{Conn: conn, r: io.MultiReader(bytes.NewReader(prefix), brw)}, nil
+}
+
+type h2cConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *h2cConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// h2cRequestFields возвращает поля заголовков HTTP/2 для запроса HTTP/1.1.
+func h2cRequestFields(r *http.Request) [][2]string {
+	fields := [][2]string{
+		{":method", r.Method},
+		{":scheme", "http"},
+		{":authority", r.Host},
+		{":path", r.URL.RequestURI()},
+	}
+	for key, values := range r.Header {
+		if h2cHopHeaders[key] {
+			continue
+		}
+		name := strings.ToLower(key)
+		for _, v := range values {
+			if name == "te" && v != "trailers" {
+				continue
+			}
+			fields = append(fields, [2]string{name, v})
+		}
+	}
+	return fields
+}
+
+// appendHeaderFrames кодирует поля как HPACK literal without indexing и разбивает блок
+// на HEADERS и CONTINUATION; поток закрывается со стороны клиента (END_STREAM).
+func appendHeaderFrames(b []byte, stream uint32, fields [][2]string) []byte {
+	var block []byte
+	for _, f := range fields {
+		block = append(block, 0)
+		block = appendHPACKString(block, f[0])
+		block = appendHPACKString(block, f[1])
+	}
+	typ, flags := byte(h2FrameHeaders), byte(h2FlagEndStream)
+	for {
+		chunk := block
+		if len(chunk) > h2cMaxFrameSize {
+			chunk = chunk[:h2cMaxFrameSize]
+		}
+		block = block[len(chunk):]
+		if len(block) == 0 {
+			flags |= h2FlagEndHeaders
+		}
+		b = appendFrame(b, typ, flags, stream, chunk)
+		if len(block) == 0 {
+			return b
+		}
+		typ, flags = h2FrameContinuation, 0
+	}
+}
+
+func appendFrame(b []byte, typ, flags byte, stream uint32, payload []byte) []byte {
+	n := len(payload)
+	b = append(b, byte(n>>16), byte(n>>8), byte(n), typ, flags)
+	b = binary.BigEndian.AppendUint32(b, stream&0x7fffffff)
+	return append(b, payload...)
+}
+
+func appendHPACKString(b []byte, s string) []byte {
+	b = appendHPACKInt(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendHPACKInt кодирует целое с 7‑битным префиксом (RFC 7541, 5.1), без Huffman.
+func appendHPACKInt(b []byte, v uint64) []byte {
+	const limit = 1<<7 - 1
+	if v < limit {
+		return append(b, byte(v))
+	}
+	b = append(b, limit)
+	for v -= limit; v >= 128; v /= 128 {
+		b = append(b, byte(v%128)|0x80)
+	}
+	return append(b, byte(v))
+}
@@ -0,0 +1,383 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func h2cClient() *http.Client {
+	tr := &http.Transport{Protocols: new(http.Protocols)}
+	tr.Protocols.SetUnencryptedHTTP2(true)
+	return &http.Client{Transport: tr, Timeout: 5 * time.Second}
+}
+
+func TestRunH2CStreamingAndShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/proto" {
+			_, _ = io.WriteString(w, r.Proto)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s\n", r.Proto)
+		http.NewResponseController(w).Flush()
+		<-release
+		_, _ = io.WriteString(w, "done\n")
+	})
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 2 * time.Second
+	cfg.H2C = true
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, h, ln)
+	}()
+
+	base := "http://" + ln.Addr().String()
+	client := h2cClient()
+	resp, err := client.Get(base + "/stream")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку запроса: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("ожидали HTTP/2, получили %s", resp.Proto)
+	}
+	br := bufio.NewReader(resp.Body)
+	if line, err := br.ReadString('\n'); err != nil || line != "HTTP/2.0\n" {
+		t.Fatalf("ожидали первый фрагмент до завершения handler, получили %q, %v", line, err)
+	}
+
+	// HTTP/1.1 на том же порту продолжает работать.
+	r1, err := (&http.Client{Timeout: time.Second}).Get(base + "/proto")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку HTTP/1.1 запроса: %v", err)
+	}
+	b, _ := io.ReadAll(r1.Body)
+	_ = r1.Body.Close()
+	if string(b) != "HTTP/1.1" {
+		t.Fatalf("ожидали HTTP/1.1, получили %q", b)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		t.Fatalf("Run не должен завершаться до окончания активного stream: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := h2cClient().Get(base + "/proto"); err == nil {
+		t.Fatalf("после начала остановки новые соединения не должны приниматься")
+	}
+
+	close(release)
+	rest, err := io.ReadAll(br)
+	if err != nil || string(rest) != "done\n" {
+		t.Fatalf("активный stream должен завершиться штатно, получили %q, %v", rest, err)
+	}
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+}
+
+type h2Frame struct {
+	typ, flags byte
+	stream     uint32
+	payload    []byte
+}
+
+func readH2Frame(br *bufio.Reader) (h2Frame, error) {
+	var hdr [9]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return h2Frame{}, err
+	}
+	f := h2Frame{
+		typ:     hdr[3],
+		flags:   hdr[4],
+		stream:  binary.BigEndian.Uint32(hdr[5:]) & 0x7fffffff,
+		payload: make([]byte, int(hdr[0])<<16|int(hdr[1])<<8|int(hdr[2])),
+	}
+	_, err := io.ReadFull(br, f.payload)
+	return f, err
+}
+
+func TestRunH2CUpgrade(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto+" "+r.URL.Path)
+	})
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = time.Second
+	cfg.H2C = true
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, h, ln)
+	}()
+
+	dial := func() (net.Conn, *bufio.Reader) {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+		return c, bufio.NewReader(c)
+	}
+	upgrade := "Host: x\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQAAP__\r\n"
+
+	// Запрос с телом остаётся на HTTP/1.1.
+	c, br := dial()
+	_, _ = io.WriteString(c, "POST /body HTTP/1.1\r\n"+upgrade+"Content-Length: 2\r\n\r\nhi")
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("не ожидали ошибку ответа: %v", err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(b) != "HTTP/1.1 /body" {
+		t.Fatalf("запрос с телом: status=%d body=%q", resp.StatusCode, b)
+	}
+
+	c, br = dial()
+	_, _ = io.WriteString(c, "GET /one HTTP/1.1\r\n"+upgrade+"\r\n")
+	resp, err = http.ReadResponse(br, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("ожидали 101, получили %v, %v", resp, err)
+	}
+	_, _ = c.Write(appendFrame([]byte(h2cPreface), h2FrameSettings, 0, 0, nil))
+
+	// read собирает DATA потока до END_STREAM, отвечая на SETTINGS сервера.
+	read := func(stream uint32) string {
+		t.Helper()
+		var body []byte
+		for {
+			f, err := readH2Frame(br)
+			if err != nil {
+				t.Fatalf("поток %d: %v", stream, err)
+			}
+			if f.typ == h2FrameSettings && f.flags&0x1 == 0 {
+				_, _ = c.Write(appendFrame(nil, h2FrameSettings, 0x1, 0, nil))
+			}
+			if f.stream != stream {
+				continue
+			}
+			if f.typ == 0x0 {
+				body = append(body, f.payload...)
+			}
+			if (f.typ == 0x0 || f.typ == h2FrameHeaders) && f.flags&h2FlagEndStream != 0 {
+				return string(body)
+			}
+		}
+	}
+	if got := read(1); got != "HTTP/2.0 /one" {
+		t.Fatalf("ответ на исходный запрос: %q", got)
+	}
+
+	_, _ = c.Write(appendHeaderFrames(nil, 3, [][2]string{
+		{":method", "GET"}, {":scheme", "http"}, {":authority", "x"}, {":path", "/two"},
+	}))
+	if got := read(3); got != "HTTP/2.0 /two" {
+		t.Fatalf("ответ на второй поток: %q", got)
+	}
+
+	cancel()
+	for {
+		f, err := readH2Frame(br)
+		if err != nil {
+			t.Fatalf("ожидали GOAWAY при остановке: %v", err)
+		}
+		if f.typ == 0x7 {
+			break
+		}
+	}
+	// Как обычный клиент: после GOAWAY без активных потоков соединение закрывается.
+	_ = c.Close()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+}
+
+func TestRunH2CWithTLS(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.H2C = true
+	cfg.TLS = &TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}
+	err = runWithListener(context.Background(), cfg, http.NotFoundHandler(), ln)
+	if !errors.Is(err, errH2CWithTLS) {
+		t.Fatalf("ожидали errH2CWithTLS, получили %v", err)
+	}
+}
+
+func TestAppendHeaderFramesContinuation(t *testing.T) {
+	big := strings.Repeat("a", 20000)
+	b := appendHeaderFrames(nil, 1, [][2]string{{":path", "/"}, {"x-big", big}})
+	br := bufio.NewReader(bytes.NewReader(b))
+	var frames []h2Frame
+	var block []byte
+	for {
+		f, err := readH2Frame(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("некорректный кадр: %v", err)
+		}
+		frames = append(frames, f)
+		block = append(block, f.payload...)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("ожидали HEADERS и CONTINUATION, получили %d кадров", len(frames))
+	}
+	if f := frames[0]; f.typ != h2FrameHeaders || f.flags != h2FlagEndStream || len(f.payload) != h2cMaxFrameSize {
+		t.Fatalf("неожиданный HEADERS: type=%d flags=%d len=%d", f.typ, f.flags, len(f.payload))
+	}
+	if f := frames[1]; f.typ != h2FrameContinuation || f.flags != h2FlagEndHeaders || f.stream != 1 {
+		t.Fatalf("неожиданный CONTINUATION: type=%d flags=%d stream=%d", f.typ, f.flags, f.stream)
+	}
+	want := appendHPACKString(appendHPACKString([]byte{0}, "x-big"), big)
+	if !bytes.HasSuffix(block, want) {
+		t.Fatalf("блок заголовков собран неверно")
+	}
+	// 20000 = 127 + 19873: префикс 0x7f и два байта продолжения.
+	if got := appendHPACKInt(nil, 20000); !bytes.Equal(got, []byte{0x7f, 0xa1, 0x9b, 0x01}) {
+		t.Fatalf("HPACK int: %x", got)
+	}
+}
+
+func TestRunH2CUpgradeSettingsAndLargeHeaders(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if big := r.Header.Get("X-Big"); big != "" {
+			_, _ = io.WriteString(w, strconv.Itoa(len(big)))
+			return
+		}
+		_, _ = io.WriteString(w, "hello world")
+	})
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = time.Second
+	cfg.H2C = true
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, h, ln)
+	}()
+
+	// upgrade отправляет запрос с Upgrade: h2c и пустой SETTINGS после preface.
+	upgrade := func(settings []byte, extra string) (net.Conn, *bufio.Reader) {
+		t.Helper()
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+		br := bufio.NewReader(c)
+		_, _ = io.WriteString(c, "GET / HTTP/1.1\r\nHost: x\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\n"+
+			"HTTP2-Settings: "+base64.RawURLEncoding.EncodeToString(settings)+"\r\n"+extra+"\r\n")
+		resp, err := http.ReadResponse(br, nil)
+		if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("ожидали 101, получили %v, %v", resp, err)
+		}
+		_, _ = c.Write(appendFrame([]byte(h2cPreface), h2FrameSettings, 0, 0, nil))
+		return c, br
+	}
+
+	// INITIAL_WINDOW_SIZE = 5 из HTTP2-Settings ограничивает первый DATA потока 1.
+	first, br := upgrade([]byte{0, 4, 0, 0, 0, 5}, "")
+	var body []byte
+	acks := 0
+	for {
+		f, err := readH2Frame(br)
+		if err != nil {
+			t.Fatalf("поток 1: %v", err)
+		}
+		if f.typ == h2FrameSettings {
+			if f.flags&h2FlagAck != 0 {
+				acks++
+			} else {
+				_, _ = first.Write(appendFrame(nil, h2FrameSettings, h2FlagAck, 0, nil))
+			}
+		}
+		if f.stream != 1 || f.typ != 0x0 {
+			continue
+		}
+		if body == nil {
+			if string(f.payload) != "hello" || f.flags&h2FlagEndStream != 0 {
+				t.Fatalf("окно из HTTP2-Settings не применено: DATA %q flags=%d", f.payload, f.flags)
+			}
+			_, _ = first.Write(appendFrame(nil, 0x8, 0, 1, binary.BigEndian.AppendUint32(nil, 100)))
+		}
+		body = append(body, f.payload...)
+		if f.flags&h2FlagEndStream != 0 {
+			break
+		}
+	}
+	if string(body) != "hello world" {
+		t.Fatalf("ответ на исходный запрос: %q", body)
+	}
+	if acks != 1 {
+		t.Fatalf("ожидали один SETTINGS ACK, получили %d", acks)
+	}
+
+	// Заголовки больше кадра уходят в HEADERS и CONTINUATION.
+	second, br := upgrade(nil, "X-Big: "+strings.Repeat("b", 40000)+"\r\n")
+	body = nil
+	for {
+		f, err := readH2Frame(br)
+		if err != nil {
+			t.Fatalf("поток 1: %v", err)
+		}
+		if f.typ == h2FrameSettings && f.flags&h2FlagAck == 0 {
+			_, _ = second.Write(appendFrame(nil, h2FrameSettings, h2FlagAck, 0, nil))
+		}
+		if f.typ == 0x7 {
+			t.Fatalf("сервер закрыл соединение: GOAWAY %x", f.payload)
+		}
+		if f.stream == 1 && f.typ == 0x0 {
+			body = append(body, f.payload...)
+			if f.flags&h2FlagEndStream != 0 {
+				break
+			}
+		}
+	}
+	if string(body) != "40000" {
+		t.Fatalf("большой заголовок: %q", body)
+	}
+
+	cancel()
+	// Клиенты без активных потоков закрывают соединения после GOAWAY.
+	_ = first.Close()
+	_ = second.Close()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+}
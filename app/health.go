@@ -2,13 +2,15 @@ package app
 
 import "net/http"
 
-// WithHealth оборачивает handler так, чтобы добавить GET /healthz.
+// WithHealth оборачивает handler так, чтобы добавить GET/HEAD /healthz.
 func WithHealth(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/healthz" && r.Method == http.MethodGet {
+		if r.URL.Path == "/healthz" && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
 			w.WriteHeader(http.StatusOK)
-			_, _ = w.Write([]byte(`{"status":"ok"}`))
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"status":"ok"}`))
+			}
 			return
 		}
 		h.ServeHTTP(w, r)
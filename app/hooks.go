@@ -9,6 +9,7 @@ import (
 	"time"
 
 	apperrors "github.com/sejta/nope/errors"
+	"github.com/sejta/nope/internal/routeinfo"
 )
 
 // Hooks описывает точки расширения для логирования и метрик.
@@ -20,8 +21,11 @@ type Hooks struct {
 
 // RequestInfo содержит минимальные данные о запросе.
 type RequestInfo struct {
-	Method string
-	Path   string // фактический URL.Path, не pattern
+	Method  string
+	Path    string // фактический URL.Path, не pattern
+	Pattern string // шаблон маршрута nope router; пуст в OnRequestStart и без совпадения
+	// Unmatched — nope router обработал запрос без совпадения маршрута (404, 405, OPTIONS, redirect).
+	Unmatched bool
 }
 
 // ResponseInfo содержит итоговые данные об ответе.
@@ -115,16 +119,18 @@ func wrapHooks(h http.Handler, hooks Hooks) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		reqInfo := RequestInfo{Method: r.Method, Path: r.URL.Path}
-		ctx := r.Context()
+		ctx, slot := routeinfo.WithSlot(r.Context())
 		if hooks.OnRequestStart != nil {
 			ctx = hooks.OnRequestStart(ctx, reqInfo)
-			r = r.WithContext(ctx)
 		}
+		r = r.WithContext(ctx)
 
 		rec := &statusRecorder{ResponseWriter: w}
 		var hookErr error
 
 		defer func() {
+			reqInfo.Pattern = slot.Pattern()
+			reqInfo.Unmatched = slot.Unmatched()
 			if recov := recover(); recov != nil {
 				if hooks.OnPanic != nil {
 					hooks.OnPanic(ctx, reqInfo, recov)
@@ -10,6 +10,7 @@ import (
 
 	apperrors "github.com/sejta/nope/errors"
 	"github.com/sejta/nope/httpkit"
+	"github.com/sejta/nope/router"
 )
 
 type ctxKey string
@@ -206,6 +207,73 @@ func TestHooksPanicAfterWrite(t *testing.T) {
 	}
 }
 
+func TestHooksRoutePattern(t *testing.T) {
+	var startPattern, endPattern string
+	hooks := Hooks{
+		OnRequestStart: func(ctx context.Context, info RequestInfo) context.Context {
+			startPattern = info.Pattern
+			return ctx
+		},
+		OnRequestEnd: func(ctx context.Context, info RequestInfo, res ResponseInfo) {
+			endPattern = info.Pattern
+		},
+	}
+
+	api := router.New()
+	api.GET("/posts/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	root := router.New()
+	root.Mount("/api", api)
+
+	h := wrapHooks(root, hooks)
+	req := httptest.NewRequest(http.MethodGet, "/api/posts/42", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if startPattern != "" {
+		t.Fatalf("ожидали пустой pattern на старте, получили %q", startPattern)
+	}
+	if endPattern != "/api/posts/:id" {
+		t.Fatalf("ожидали pattern %q, получили %q", "/api/posts/:id", endPattern)
+	}
+}
+
+func TestHooksRouteUnmatched(t *testing.T) {
+	var got RequestInfo
+	hooks := Hooks{
+		OnRequestEnd: func(ctx context.Context, info RequestInfo, res ResponseInfo) {
+			got = info
+		},
+	}
+	api := router.New()
+	api.GET("/posts/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	root := router.New()
+	root.Mount("/api", api)
+	h := wrapHooks(root, hooks)
+
+	cases := []struct {
+		method, path string
+		unmatched    bool
+	}{
+		{http.MethodGet, "/wp-admin/login.php", true},
+		{http.MethodGet, "/api/nope", true},
+		{http.MethodPost, "/api/posts/1", true},
+		{http.MethodOptions, "/api/posts/1", true},
+		{http.MethodGet, "/api/posts/1", false},
+	}
+	for _, tc := range cases {
+		got = RequestInfo{}
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+		if got.Unmatched != tc.unmatched {
+			t.Fatalf("%s %s: ожидали Unmatched=%v, получили %+v", tc.method, tc.path, tc.unmatched, got)
+		}
+		if tc.unmatched && got.Pattern != "" {
+			t.Fatalf("%s %s: ожидали пустой pattern, получили %q", tc.method, tc.path, got.Pattern)
+		}
+	}
+}
+
 func TestHooksNoop(t *testing.T) {
 	h := wrapHooks(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ConnGauge получает текущее число соединений listener в состоянии state
+// (http.StateNew, http.StateActive, http.StateIdle) при каждом изменении.
+type ConnGauge interface {
+	SetConns(listener string, state http.ConnState, n int64)
+}
+
+var errConnPerIPLimit = errors.New("app: too many connections from remote ip")
+
+// limitListener ограничивает число одновременных соединений: сверх лимита Accept
+// ждёт освобождения слота.
+type limitListener struct {
+	net.Listener
+	sem  chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newLimitListener(ln net.Listener, maxConns int) net.Listener {
+	if maxConns <= 0 {
+		return ln
+	}
+	return &limitListener{Listener: ln, sem: make(chan struct{}, maxConns), done: make(chan struct{})}
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.sem <- struct{}{}:
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+	c, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+	return &limitConn{Conn: c, sem: l.sem}, nil
+}
+
+func (l *limitListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+type limitConn struct {
+	net.Conn
+	sem  chan struct{}
+	once sync.Once
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { <-c.sem })
+	return err
+}
+
+// ipLimitListener ограничивает число одновременных соединений с одного IP.
+//
+// IP берётся из RemoteAddr соединения при первом чтении, то есть в горутине соединения
+// и после разбора PROXY protocol: за балансировщиком считается адрес клиента.
+// Лишнее соединение закрывается до чтения запроса.
+type ipLimitListener struct {
+	net.Listener
+	max int
+	mu  sync.Mutex
+	ips map[string]int
+}
+
+func newIPLimitListener(ln net.Listener, perIP int) net.Listener {
+	if perIP <= 0 {
+		return ln
+	}
+	return &ipLimitListener{Listener: ln, max: perIP, ips: map[string]int{}}
+}
+
+func (l *ipLimitListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &ipLimitConn{Conn: c, l: l}, nil
+}
+
+func (l *ipLimitListener) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ips[ip] >= l.max {
+		return false
+	}
+	l.ips[ip]++
+	return true
+}
+
+func (l *ipLimitListener) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ips[ip]--; l.ips[ip] <= 0 {
+		delete(l.ips, ip)
+	}
+}
+
+type ipLimitConn struct {
+	net.Conn
+	l        *ipLimitListener
+	admit    sync.Once
+	released sync.Once
+	ip       string
+	ok       bool
+}
+
+// check занимает слот IP один раз; при превышении лимита закрывает соединение.
+func (c *ipLimitConn) check() {
+	c.admit.Do(func() {
+		c.ip = remoteIP(c.Conn.RemoteAddr())
+		if c.ok = c.l.acquire(c.ip); !c.ok {
+			_ = c.Conn.Close()
+		}
+	})
+}
+
+func (c *ipLimitConn) Read(p []byte) (int, error) {
+	c.check()
+	if !c.ok {
+		return 0, errConnPerIPLimit
+	}
+	return c.Conn.Read(p)
+}
+
+func (c *ipLimitConn) Write(p []byte) (int, error) {
+	c.check()
+	if !c.ok {
+		return 0, errConnPerIPLimit
+	}
+	return c.Conn.Write(p)
+}
+
+func (c *ipLimitConn) RemoteAddr() net.Addr {
+	c.check()
+	return c.Conn.RemoteAddr()
+}
+
+func (c *ipLimitConn) Close() error {
+	// Закрытое до проверки соединение слот не занимает.
+	c.admit.Do(func() {})
+	err := c.Conn.Close()
+	c.released.Do(func() {
+		if c.ok {
+			c.l.release(c.ip)
+		}
+	})
+	return err
+}
+
+func remoteIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// connTracker считает соединения по состояниям для ConnGauge.
+type connTracker struct {
+	name   string
+	gauge  ConnGauge
+	mu     sync.Mutex
+	states map[net.Conn]http.ConnState
+	counts map[http.ConnState]int64
+}
+
+func newConnTracker(name string, gauge ConnGauge) *connTracker {
+	return &connTracker{
+		name:   name,
+		gauge:  gauge,
+		states: map[net.Conn]http.ConnState{},
+		counts: map[http.ConnState]int64{},
+	}
+}
+
+func (t *connTracker) track(c net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if prev, ok := t.states[c]; ok {
+		t.counts[prev]--
+		t.gauge.SetConns(t.name, prev, t.counts[prev])
+	}
+	switch state {
+	case http.StateHijacked, http.StateClosed:
+		delete(t.states, c)
+	default:
+		t.states[c] = state
+		t.counts[state]++
+		t.gauge.SetConns(t.name, state, t.counts[state])
+	}
+}
@@ -0,0 +1,229 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIPLimitListener(t *testing.T) {
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ln := newIPLimitListener(raw, 1)
+	defer ln.Close()
+
+	accept := func() net.Conn {
+		t.Helper()
+		client, err := net.Dial("tcp", raw.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("accept: %v", err)
+		}
+		return c
+	}
+
+	held := accept()
+	_ = held.RemoteAddr()
+
+	extra := accept()
+	if _, err := extra.Read(make([]byte, 1)); !errors.Is(err, errConnPerIPLimit) {
+		t.Fatalf("лишнее соединение с того же IP должно закрываться, получили %v", err)
+	}
+	_ = extra.Close()
+
+	_ = held.Close()
+	next := accept()
+	defer next.Close()
+	_ = next.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := next.Read(make([]byte, 1)); errors.Is(err, errConnPerIPLimit) {
+		t.Fatalf("после закрытия соединения слот IP должен освобождаться")
+	}
+}
+
+func TestRunConnPerIPBehindProxy(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+	cfg.MaxConnsPerIP = 1
+	cfg.ProxyProtocol = &ProxyProtocolConfig{TrustedCIDRs: []string{"127.0.0.0/8"}}
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, http.NotFoundHandler(), ln)
+	}()
+
+	// Keep-alive соединение держит слот своего клиента.
+	open := func(src string) *bufio.Reader {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		_ = c.SetDeadline(time.Now().Add(2 * time.Second))
+		header := "PROXY TCP4 " + src + " 10.0.0.1 40000 80\r\n"
+		if _, err := io.WriteString(c, header+"GET / HTTP/1.1\r\nHost: x\r\n\r\n"); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		return bufio.NewReader(c)
+	}
+	status := func(br *bufio.Reader) int {
+		resp, err := http.ReadResponse(br, nil)
+		if err != nil {
+			return 0
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := status(open("203.0.113.1")); code != http.StatusNotFound {
+		t.Fatalf("первый клиент: ожидали 404, получили %d", code)
+	}
+	if code := status(open("203.0.113.2")); code != http.StatusNotFound {
+		t.Fatalf("другой клиент за тем же балансировщиком должен обслуживаться, получили %d", code)
+	}
+	if code := status(open("203.0.113.1")); code != 0 {
+		t.Fatalf("второе соединение того же клиента должно закрываться, получили %d", code)
+	}
+
+	cancel()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+}
+
+func TestLimitListenerMaxConns(t *testing.T) {
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ln := newLimitListener(raw, 1)
+
+	held, err := net.Dial("tcp", raw.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer held.Close()
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+
+	next := make(chan error, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			_ = c.Close()
+		}
+		next <- err
+	}()
+	select {
+	case <-next:
+		t.Fatalf("Accept должен ждать свободный слот")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_ = ln.Close()
+	select {
+	case err := <-next:
+		if err == nil {
+			t.Fatalf("после Close Accept должен вернуть ошибку")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Close должен разблокировать Accept")
+	}
+	_ = c.Close()
+}
+
+type gaugeRecorder struct {
+	mu   sync.Mutex
+	last map[http.ConnState]int64
+}
+
+func (g *gaugeRecorder) SetConns(_ string, state http.ConnState, n int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.last[state] = n
+}
+
+func (g *gaugeRecorder) get(state http.ConnState) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.last[state]
+}
+
+func TestRunConnLimitsAndGauge(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gauge := &gaugeRecorder{last: map[http.ConnState]int64{}}
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+	cfg.MaxConnsPerIP = 4
+	cfg.MaxHeaderBytes = 1 << 10
+	cfg.ConnGauge = gauge
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, http.NotFoundHandler(), ln)
+	}()
+
+	client := &http.Client{Timeout: time.Second}
+	req, _ := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+"/", nil)
+	req.Header.Set("X-Big", strings.Repeat("x", 8<<10))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("не ожидали ошибку запроса: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("ожидали 431 при превышении MaxHeaderBytes, получили %d", resp.StatusCode)
+	}
+
+	resp, err = client.Get("http://" + ln.Addr().String() + "/")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку запроса: %v", err)
+	}
+	_ = resp.Body.Close()
+	deadline := time.Now().Add(time.Second)
+	for gauge.get(http.StateIdle) != 1 || gauge.get(http.StateActive) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("ожидали 1 idle и 0 active соединений, получили %d и %d",
+				gauge.get(http.StateIdle), gauge.get(http.StateActive))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	client.CloseIdleConnections()
+	cancel()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+	deadline = time.Now().Add(time.Second)
+	for gauge.get(http.StateIdle) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("после остановки ожидали 0 idle соединений, получили %d", gauge.get(http.StateIdle))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
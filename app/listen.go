@@ -0,0 +1,140 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Префиксы адресов для Config.Addr и Listener.Addr.
+const (
+	// UnixPrefix — Unix domain socket: "unix:/run/app.sock".
+	UnixPrefix = "unix:"
+	// SystemdPrefix — listener из systemd socket activation: "systemd:http" (по LISTEN_FDNAMES)
+	// или "systemd:0" (по порядковому номеру).
+	SystemdPrefix = "systemd:"
+)
+
+// DefaultSocketMode — права Unix socket, если Config.SocketMode не задан.
+const DefaultSocketMode os.FileMode = 0o660
+
+const listenFDsStart = 3
+
+var errSocketInUse = errors.New("app: unix socket is in use")
+
+// listen открывает listener по адресу: TCP, unix:PATH или systemd:NAME.
+func listen(addr string, mode os.FileMode) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, UnixPrefix):
+		return listenUnix(strings.TrimPrefix(addr, UnixPrefix), mode)
+	case strings.HasPrefix(addr, SystemdPrefix):
+		return systemdListener(strings.TrimPrefix(addr, SystemdPrefix))
+	default:
+		return net.Listen("tcp", addr)
+	}
+}
+
+// listenUnix создаёт Unix socket, удаляя оставшийся от упавшего процесса файл.
+// Socket, на котором кто-то слушает, не трогается.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if path == "" {
+		return nil, fmt.Errorf("app: empty unix socket path")
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("app: %s exists and is not a socket", path)
+		}
+		if c, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("%w: %s", errSocketInUse, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("app: remove stale socket: %w", err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode == 0 {
+		mode = DefaultSocketMode
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("app: chmod unix socket: %w", err)
+	}
+	return ln, nil
+}
+
+type systemdFD struct {
+	name string
+	ln   net.Listener
+	err  error
+	used bool
+}
+
+var (
+	systemdOnce sync.Once
+	systemdMu   sync.Mutex
+	systemdFDs  []*systemdFD
+)
+
+// systemdListener возвращает унаследованный listener; каждый можно забрать один раз.
+func systemdListener(name string) (net.Listener, error) {
+	systemdOnce.Do(loadSystemdFDs)
+	systemdMu.Lock()
+	defer systemdMu.Unlock()
+
+	if len(systemdFDs) == 0 {
+		return nil, fmt.Errorf("app: no systemd sockets (LISTEN_FDS) for %q", name)
+	}
+	for i, fd := range systemdFDs {
+		if fd.name != name && strconv.Itoa(i) != name {
+			continue
+		}
+		if fd.used {
+			return nil, fmt.Errorf("app: systemd socket %q already used", name)
+		}
+		if fd.err != nil {
+			return nil, fd.err
+		}
+		fd.used = true
+		return fd.ln, nil
+	}
+	return nil, fmt.Errorf("app: systemd socket %q not found in LISTEN_FDNAMES", name)
+}
+
+// loadSystemdFDs читает LISTEN_PID/LISTEN_FDS/LISTEN_FDNAMES (sd_listen_fds)
+// и очищает их, чтобы переменные не унаследовали дочерние процессы.
+func loadSystemdFDs() {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < n; i++ {
+		fd := &systemdFD{}
+		if i < len(names) {
+			fd.name = names[i]
+		}
+		f := os.NewFile(uintptr(listenFDsStart+i), fd.name)
+		// FileListener дублирует fd с CLOEXEC, исходный закрываем.
+		fd.ln, fd.err = net.FileListener(f)
+		_ = f.Close()
+		systemdFDs = append(systemdFDs, fd)
+	}
+}
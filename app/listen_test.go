@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func skipWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets и передача fd не поддерживаются на windows")
+	}
+}
+
+func TestRunUnixSocket(t *testing.T) {
+	skipWindows(t)
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	// Оставшийся от упавшего процесса socket‑файл.
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	stale.SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg := DefaultConfig()
+	cfg.Addr = UnixPrefix + path
+	cfg.SocketMode = 0o600
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, cfg, WithHealth(http.NotFoundHandler()))
+	}()
+
+	client := &http.Client{Timeout: 200 * time.Millisecond, Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r, err := client.Get("http://unix/healthz")
+		if err == nil {
+			_ = r.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("не дождались /healthz через unix socket: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Fatalf("ожидали права 0600, получили %v", fi.Mode().Perm())
+	}
+	if _, err := listen(UnixPrefix+path, 0); !errors.Is(err, errSocketInUse) {
+		t.Fatalf("занятый socket не должен удаляться, получили %v", err)
+	}
+
+	cancel()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket должен удаляться при остановке: %v", err)
+	}
+}
+
+func TestRunListenerNil(t *testing.T) {
+	if err := RunListener(context.Background(), DefaultConfig(), http.NotFoundHandler(), nil); !errors.Is(err, ErrNilListener) {
+		t.Fatalf("ожидали ErrNilListener, получили %v", err)
+	}
+}
+
+func TestRunListenerUpgradeRequiresListen(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Upgrade = &UpgradeConfig{}
+	if err := RunListener(context.Background(), cfg, http.NotFoundHandler(), ln); !errors.Is(err, ErrUpgradeListener) {
+		t.Fatalf("ожидали ErrUpgradeListener, получили %v", err)
+	}
+	if _, err := ln.Accept(); err == nil {
+		t.Fatalf("listener должен закрываться при ошибке")
+	}
+}
+
+func TestSystemdListener(t *testing.T) {
+	skipWindows(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("не удалось получить fd: %v", err)
+	}
+	defer f.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSystemdHelper$")
+	cmd.Env = append(os.Environ(), "NOPE_TEST_SYSTEMD_CHILD=1", "LISTEN_FDS=1", "LISTEN_FDNAMES=http")
+	cmd.ExtraFiles = []*os.File{f}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("дочерний процесс: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "addr="+ln.Addr().String()) {
+		t.Fatalf("ожидали унаследованный listener %s, вывод:\n%s", ln.Addr(), out)
+	}
+}
+
+// TestSystemdHelper выполняется в дочернем процессе TestSystemdListener.
+func TestSystemdHelper(t *testing.T) {
+	if os.Getenv("NOPE_TEST_SYSTEMD_CHILD") != "1" {
+		t.Skip("helper process")
+	}
+	// LISTEN_PID выставляет systemd после fork; здесь — сам дочерний процесс.
+	_ = os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+
+	if _, err := listen(SystemdPrefix+"missing", 0); err == nil {
+		t.Fatalf("ожидали ошибку для неизвестного имени")
+	}
+	ln, err := listen(SystemdPrefix+"http", 0)
+	if err != nil {
+		t.Fatalf("не ожидали ошибку: %v", err)
+	}
+	if _, err := listen(SystemdPrefix+"0", 0); err == nil {
+		t.Fatalf("listener нельзя забрать дважды")
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Fatalf("LISTEN_FDS должен очищаться")
+	}
+	fmt.Printf("addr=%s\n", ln.Addr())
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+const mainListener = "main"
+
+var errH2CWithTLS = errors.New("app: h2c requires a plaintext listener")
+
+// Listener — дополнительный именованный listener со своим handler и hooks,
+// например admin‑порт 127.0.0.1:9090 для pprof и health отдельно от публичного API.
+//
+// Таймауты, лимиты соединений и HTTP/2 берутся из Config (лимиты — на каждый listener);
+// остановка общая для всех listeners.
+type Listener struct {
+	Name    string
+	Addr    string
+	Handler http.Handler
+	Hooks   Hooks
+	TLS     *TLSConfig // nil — plaintext
+	// ProxyProtocol включает разбор PROXY protocol на этом listener.
+	ProxyProtocol *ProxyProtocolConfig
+	// H2C включает HTTP/2 без TLS на этом listener, см. Config.H2C.
+	H2C bool
+}
+
+func (l Listener) name() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.Addr
+}
+
+type endpoint struct {
+	name   string
+	srv    *http.Server
+	ln     net.Listener // исходный listener; его fd передаётся при обновлении
+	served net.Listener // ln с обёртками (лимиты соединений, PROXY protocol)
+}
+
+type endpointErr struct {
+	ep  *endpoint
+	err error
+}
+
+// error возвращает ошибку Serve; штатное закрытие — nil.
+func (r endpointErr) error() error {
+	if r.err == nil || r.err == http.ErrServerClosed {
+		return nil
+	}
+	if r.ep.name == mainListener {
+		return r.err
+	}
+	return listenerErr(r.ep.name, r.err)
+}
+
+// alpnProtocols возвращает протоколы ALPN для p в порядке предпочтения.
+func alpnProtocols(p *http.Protocols) []string {
+	var out []string
+	if p.HTTP2() {
+		out = append(out, "h2")
+	}
+	if p.HTTP1() {
+		out = append(out, "http/1.1")
+	}
+	return out
+}
+
+func newEndpoint(ctx context.Context, cfg Config, l Listener, ln net.Listener) (*endpoint, error) {
+	if l.Handler == nil {
+		if l.Name == mainListener {
+			return nil, ErrNilHandler
+		}
+		return nil, listenerErr(l.name(), ErrNilHandler)
+	}
+	srv := &http.Server{
+		Handler:           wrapHooks(l.Handler, l.Hooks),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		HTTP2:             cfg.HTTP2,
+	}
+	if cfg.ConnGauge != nil {
+		srv.ConnState = newConnTracker(l.name(), cfg.ConnGauge).track
+	}
+	if l.H2C {
+		if l.TLS != nil {
+			if l.Name == mainListener {
+				return nil, errH2CWithTLS
+			}
+			return nil, listenerErr(l.name(), errH2CWithTLS)
+		}
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	if l.TLS != nil {
+		reloader, err := newCertReloader(*l.TLS)
+		if err != nil {
+			if l.Name == mainListener {
+				return nil, err
+			}
+			return nil, listenerErr(l.name(), err)
+		}
+		if srv.Protocols == nil {
+			srv.Protocols = new(http.Protocols)
+			srv.Protocols.SetHTTP1(true)
+			srv.Protocols.SetHTTP2(true)
+		}
+		srv.TLSConfig = reloader.tlsConfig(alpnProtocols(srv.Protocols))
+		go reloader.watch(ctx)
+	}
+	served := newLimitListener(ln, cfg.MaxConns)
+	if l.ProxyProtocol != nil {
+		pl, err := NewProxyListener(served, *l.ProxyProtocol)
+		if err != nil {
+			if l.Name == mainListener {
+				return nil, err
+			}
+			return nil, listenerErr(l.name(), err)
+		}
+		served = pl
+	}
+	// Лимит на IP снаружи PROXY protocol: считается адрес клиента, а не балансировщика.
+	served = newIPLimitListener(served, cfg.MaxConnsPerIP)
+	if l.H2C {
+		hl := newH2CListener(served)
+		srv.Handler = h2cUpgrade(srv.Handler, hl, cfg.ReadHeaderTimeout)
+		served = hl
+	}
+	return &endpoint{name: l.name(), srv: srv, ln: ln, served: served}, nil
+}
+
+func (ep *endpoint) serve() error {
+	if ep.srv.TLSConfig != nil {
+		return ep.srv.ServeTLS(ep.served, "", "")
+	}
+	return ep.srv.Serve(ep.served)
+}
+
+func listenerErr(name string, err error) error {
+	return fmt.Errorf("app: listener %s: %w", name, err)
+}
+
+func closeListeners(lns []net.Listener) {
+	for _, ln := range lns {
+		_ = ln.Close()
+	}
+}
@@ -0,0 +1,214 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProxyHeaderTimeout — таймаут чтения заголовка PROXY protocol.
+const DefaultProxyHeaderTimeout = 5 * time.Second
+
+const proxyV1MaxLen = 107
+
+var (
+	proxyV2Sig = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	errProxyNoTrusted = errors.New("app: proxy protocol requires TrustedCIDRs")
+	errProxyHeader    = errors.New("app: invalid proxy protocol header")
+)
+
+// ProxyProtocolConfig включает разбор PROXY protocol v1/v2 (AWS NLB, HAProxy в TCP mode).
+//
+// Заголовок читается только от адресов из TrustedCIDRs; адрес клиента из него становится
+// r.RemoteAddr. Соединение от доверенного источника без заголовка обслуживается как есть,
+// от недоверенного — заголовок не разбирается. Некорректный заголовок закрывает соединение.
+type ProxyProtocolConfig struct {
+	// TrustedCIDRs — адреса балансировщиков, например "10.0.0.0/8"; обязательно.
+	TrustedCIDRs []string
+	// HeaderTimeout — таймаут чтения заголовка; 0 — DefaultProxyHeaderTimeout.
+	HeaderTimeout time.Duration
+}
+
+// NewProxyListener оборачивает ln разбором PROXY protocol.
+// Заголовок читается в горутине соединения, а не в Accept.
+func NewProxyListener(ln net.Listener, cfg ProxyProtocolConfig) (net.Listener, error) {
+	if len(cfg.TrustedCIDRs) == 0 {
+		return nil, errProxyNoTrusted
+	}
+	trusted := make([]netip.Prefix, 0, len(cfg.TrustedCIDRs))
+	for _, cidr := range cfg.TrustedCIDRs {
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("app: proxy protocol trusted cidr %q: %w", cidr, err)
+		}
+		trusted = append(trusted, p.Masked())
+	}
+	timeout := cfg.HeaderTimeout
+	if timeout <= 0 {
+		timeout = DefaultProxyHeaderTimeout
+	}
+	return &proxyListener{Listener: ln, trusted: trusted, timeout: timeout}, nil
+}
+
+type proxyListener struct {
+	net.Listener
+	trusted []netip.Prefix
+	timeout time.Duration
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.isTrusted(c.RemoteAddr()) {
+		return c, nil
+	}
+	return &proxyConn{Conn: c, br: bufio.NewReader(c), timeout: l.timeout}, nil
+}
+
+func (l *proxyListener) isTrusted(addr net.Addr) bool {
+	ap, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := ap.Addr().Unmap()
+	for _, p := range l.trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type proxyConn struct {
+	net.Conn
+	br      *bufio.Reader
+	timeout time.Duration
+	once    sync.Once
+	remote  net.Addr
+	err     error
+}
+
+func (c *proxyConn) Read(p []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.br.Read(p)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyConn) readHeader() {
+	_ = c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	defer c.Conn.SetReadDeadline(time.Time{})
+
+	first, err := c.br.Peek(1)
+	if err != nil {
+		c.err = err
+		return
+	}
+	switch first[0] {
+	case 'P':
+		if b, err := c.br.Peek(6); err == nil && string(b) == "PROXY " {
+			c.remote, c.err = readProxyV1(c.br)
+		}
+	case '\r':
+		if b, err := c.br.Peek(len(proxyV2Sig)); err == nil && bytes.Equal(b, proxyV2Sig) {
+			c.remote, c.err = readProxyV2(c.br)
+		}
+	}
+	if c.err != nil {
+		_ = c.Conn.Close()
+	}
+}
+
+// readProxyV1 разбирает "PROXY TCP4 src dst sport dport\r\n"; UNKNOWN оставляет исходный адрес.
+func readProxyV1(br *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < proxyV1MaxLen {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errProxyHeader
+	}
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errProxyHeader
+	}
+	ip, err := netip.ParseAddr(fields[2])
+	if err != nil || ip.Is4() != (fields[1] == "TCP4") {
+		return nil, errProxyHeader
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, errProxyHeader
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(port))), nil
+}
+
+// readProxyV2 разбирает бинарный заголовок; LOCAL и не‑TCP семейства оставляют исходный адрес.
+func readProxyV2(br *bufio.Reader) (net.Addr, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, err
+	}
+	verCmd, fam := hdr[12], hdr[13]
+	if verCmd>>4 != 2 {
+		return nil, errProxyHeader
+	}
+	body := make([]byte, binary.BigEndian.Uint16(hdr[14:16]))
+	if _, err := io.ReadFull(br, body); err != nil {
+		return nil, err
+	}
+	switch verCmd & 0x0f {
+	case 0x0: // LOCAL: health check балансировщика
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, errProxyHeader
+	}
+	switch fam {
+	case 0x11: // TCP over IPv4
+		if len(body) < 12 {
+			return nil, errProxyHeader
+		}
+		ip := netip.AddrFrom4([4]byte(body[0:4]))
+		return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, binary.BigEndian.Uint16(body[8:10]))), nil
+	case 0x21: // TCP over IPv6
+		if len(body) < 36 {
+			return nil, errProxyHeader
+		}
+		ip := netip.AddrFrom16([16]byte(body[0:16]))
+		return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, binary.BigEndian.Uint16(body[32:34]))), nil
+	default:
+		return nil, nil
+	}
+}
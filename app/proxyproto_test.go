@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func proxyV2Header(src netip.AddrPort) []byte {
+	hdr := append([]byte(nil), proxyV2Sig...)
+	hdr = append(hdr, 0x21, 0x21)
+	hdr = binary.BigEndian.AppendUint16(hdr, 36)
+	dst := netip.MustParseAddr("::1").As16()
+	srcIP := src.Addr().As16()
+	hdr = append(hdr, srcIP[:]...)
+	hdr = append(hdr, dst[:]...)
+	hdr = binary.BigEndian.AppendUint16(hdr, src.Port())
+	return binary.BigEndian.AppendUint16(hdr, 443)
+}
+
+func TestProxyProtocolRemoteAddr(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+	cfg.ProxyProtocol = &ProxyProtocolConfig{TrustedCIDRs: []string{"127.0.0.0/8"}, HeaderTimeout: time.Second}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.RemoteAddr)
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, h, ln)
+	}()
+
+	send := func(prefix []byte) (string, error) {
+		c, err := net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+		if err != nil {
+			return "", err
+		}
+		defer c.Close()
+		_ = c.SetDeadline(time.Now().Add(2 * time.Second))
+		req := "GET / HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n"
+		if _, err := c.Write(append(prefix, req...)); err != nil {
+			return "", err
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(c), nil)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	cases := []struct {
+		name   string
+		prefix []byte
+		want   string
+	}{
+		{"v1", []byte("PROXY TCP4 203.0.113.7 10.0.0.1 51234 80\r\n"), "203.0.113.7:51234"},
+		{"v1 unknown", []byte("PROXY UNKNOWN\r\n"), "127.0.0.1:"},
+		{"v2", proxyV2Header(netip.MustParseAddrPort("[2001:db8::5]:4000")), "[2001:db8::5]:4000"},
+		{"без заголовка", nil, "127.0.0.1:"},
+	}
+	for _, tc := range cases {
+		got, err := send(tc.prefix)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !strings.HasPrefix(got, tc.want) {
+			t.Fatalf("%s: ожидали RemoteAddr %q, получили %q", tc.name, tc.want, got)
+		}
+	}
+
+	if _, err := send([]byte("PROXY TCP4 not-an-ip 10.0.0.1 1 2\r\n")); err == nil {
+		t.Fatalf("некорректный заголовок должен закрывать соединение")
+	}
+
+	cancel()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+}
+
+func TestProxyProtocolUntrustedSource(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ln, err := NewProxyListener(inner, ProxyProtocolConfig{TrustedCIDRs: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatalf("не ожидали ошибку: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		c, err := net.Dial("tcp", inner.Addr().String())
+		if err == nil {
+			_, _ = c.Write([]byte("PROXY TCP4 203.0.113.7 10.0.0.1 1 2\r\n"))
+			_ = c.Close()
+		}
+	}()
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer c.Close()
+	if !strings.HasPrefix(c.RemoteAddr().String(), "127.0.0.1:") {
+		t.Fatalf("заголовок недоверенного источника не должен разбираться: %s", c.RemoteAddr())
+	}
+
+	if _, err := NewProxyListener(inner, ProxyProtocolConfig{}); err != errProxyNoTrusted {
+		t.Fatalf("ожидали errProxyNoTrusted, получили %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCheckTimeout — таймаут проверки готовности, если он не задан явно.
+const DefaultCheckTimeout = 2 * time.Second
+
+// Статусы в отчёте /readyz.
+const (
+	ReadyStatusOK       = "ok"
+	ReadyStatusFail     = "fail"
+	ReadyStatusTimeout  = "timeout"
+	ReadyStatusDraining = "draining"
+)
+
+// Check проверяет зависимость (ping БД, probe upstream); nil — готово.
+type Check func(ctx context.Context) error
+
+// Readiness хранит проверки готовности и признак drain.
+// Безопасен для конкурентного использования.
+type Readiness struct {
+	mu       sync.RWMutex
+	checks   []readinessCheck
+	draining atomic.Bool
+}
+
+type readinessCheck struct {
+	name    string
+	check   Check
+	timeout time.Duration
+}
+
+// ReadinessReport — JSON-отчёт /readyz.
+type ReadinessReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult — результат одной проверки. Текст ошибки не раскрывается.
+type CheckResult struct {
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Ready сообщает, готов ли сервис принимать трафик.
+func (r ReadinessReport) Ready() bool {
+	return r.Status == ReadyStatusOK
+}
+
+// NewReadiness создаёт пустой набор проверок.
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Add регистрирует проверку; timeout <= 0 означает DefaultCheckTimeout.
+func (r *Readiness) Add(name string, check Check, timeout time.Duration) {
+	if check == nil {
+		return
+	}
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	r.mu.Lock()
+	r.checks = append(r.checks, readinessCheck{name: name, check: check, timeout: timeout})
+	r.mu.Unlock()
+}
+
+// Drain переводит сервис в режим drain: /readyz отвечает 503 без запуска проверок.
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining сообщает, включён ли drain.
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// Check выполняет проверки параллельно, каждую со своим таймаутом.
+func (r *Readiness) Check(ctx context.Context) ReadinessReport {
+	if r.Draining() {
+		return ReadinessReport{Status: ReadyStatusDraining}
+	}
+	r.mu.RLock()
+	checks := append([]readinessCheck(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := ReadinessReport{Status: ReadyStatusOK}
+	if len(checks) > 0 {
+		report.Checks = make(map[string]CheckResult, len(checks))
+	}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != ReadyStatusOK {
+			report.Status = ReadyStatusFail
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, c readinessCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	res := CheckResult{Status: ReadyStatusOK, DurationMS: time.Since(start).Milliseconds()}
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded):
+		res.Status = ReadyStatusTimeout
+	default:
+		res.Status = ReadyStatusFail
+	}
+	return res
+}
+
+// WithReadiness добавляет GET/HEAD /readyz: 200 при успехе всех проверок, иначе 503.
+func WithReadiness(h http.Handler, r *Readiness) http.Handler {
+	if r == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/readyz" || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		report := r.Check(req.Context())
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if req.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(report)
+		}
+	})
+}
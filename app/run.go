@@ -7,26 +7,71 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 )
 
 var (
 	// ErrNilHandler возвращается, когда Run вызван с nil handler.
 	ErrNilHandler = errors.New("app: handler is nil")
+	// ErrNilListener возвращается, когда RunListener вызван с nil listener.
+	ErrNilListener = errors.New("app: listener is nil")
+	// ErrUpgradeListener возвращается RunListener с Config.Upgrade, если listener получен не через Listen:
+	// новый процесс не сможет забрать его и попытается занять адрес повторно.
+	ErrUpgradeListener = errors.New("app: upgrade requires a listener from app.Listen")
 )
 
+// listened — listeners, открытые через Listen; только их RunListener передаёт при обновлении.
+var listened sync.Map
+
 // Run запускает HTTP-сервер и блокируется до остановки.
 // Сервер останавливается по отмене ctx или по SIGINT/SIGTERM.
+//
+// Addr — TCP‑адрес (":8080"), Unix socket ("unix:/run/app.sock")
+// или унаследованный systemd socket ("systemd:http").
 func Run(ctx context.Context, cfg Config, h http.Handler) error {
 	if h == nil {
 		return ErrNilHandler
 	}
 	cfg = withDefaults(cfg)
 
-	ln, err := net.Listen("tcp", cfg.Addr)
+	ln, err := Listen(cfg)
 	if err != nil {
 		return err
 	}
+	return RunListener(ctx, cfg, h, ln)
+}
+
+// Listen открывает основной listener по cfg.Addr для RunListener.
+// При обновлении бинаря (Config.Upgrade) возвращает listener, переданный старым процессом.
+func Listen(cfg Config) (net.Listener, error) {
+	cfg = withDefaults(cfg)
+	ln, err := listenNamed(mainListener, cfg.Addr, cfg.SocketMode)
+	if err != nil {
+		return nil, err
+	}
+	listened.Store(ln, struct{}{})
+	return ln, nil
+}
+
+// RunListener работает как Run, но обслуживает готовый listener вместо cfg.Addr.
+// Listener закрывается при остановке. С Config.Upgrade listener нужно получить через Listen.
+func RunListener(ctx context.Context, cfg Config, h http.Handler, ln net.Listener) error {
+	if h == nil {
+		if ln != nil {
+			listened.Delete(ln)
+			_ = ln.Close()
+		}
+		return ErrNilHandler
+	}
+	if ln == nil {
+		return ErrNilListener
+	}
+	if _, ok := listened.LoadAndDelete(ln); !ok && cfg.Upgrade != nil {
+		_ = ln.Close()
+		return ErrUpgradeListener
+	}
 
 	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -36,44 +81,140 @@ func Run(ctx context.Context, cfg Config, h http.Handler) error {
 
 func runWithListener(ctx context.Context, cfg Config, h http.Handler, ln net.Listener) error {
 	cfg = withDefaults(cfg)
-	h = wrapHooks(h, cfg.Hooks)
+	lns := []net.Listener{ln}
+	for _, l := range cfg.Listeners {
+		extra, err := listenNamed(l.name(), l.Addr, cfg.SocketMode)
+		if err != nil {
+			closeListeners(lns)
+			return listenerErr(l.name(), err)
+		}
+		lns = append(lns, extra)
+	}
+	return serve(ctx, cfg, h, lns)
+}
+
+// serve обслуживает основной listener (lns[0]) и cfg.Listeners (lns[1:]) с общим graceful shutdown.
+func serve(ctx context.Context, cfg Config, h http.Handler, lns []net.Listener) error {
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
 
-	srv := &http.Server{
-		Handler:           h,
-		ReadTimeout:       cfg.ReadTimeout,
-		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
-		WriteTimeout:      cfg.WriteTimeout,
-		IdleTimeout:       cfg.IdleTimeout,
+	primary := Listener{Name: mainListener, Handler: h, Hooks: cfg.Hooks, TLS: cfg.TLS, ProxyProtocol: cfg.ProxyProtocol, H2C: cfg.H2C}
+	specs := append([]Listener{primary}, cfg.Listeners...)
+	eps := make([]*endpoint, 0, len(specs))
+	for i, spec := range specs {
+		ep, err := newEndpoint(watchCtx, cfg, spec, lns[i])
+		if err != nil {
+			closeListeners(lns)
+			return err
+		}
+		eps = append(eps, ep)
 	}
 
-	errCh := make(chan error, 1)
-	go func() {
-		errCh <- srv.Serve(ln)
-	}()
+	errCh := make(chan endpointErr, len(eps))
+	for _, ep := range eps {
+		go func() {
+			errCh <- endpointErr{ep: ep, err: ep.serve()}
+		}()
+	}
 
+	// Workers стартуют после bind listeners и отменяются вместе с Shutdown (после drain).
+	workerCtx, cancelWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWorkers()
+	workers := startWorkers(workerCtx, cfg.Workers)
+
+	// Процесс, запущенный при обновлении бинаря, сообщает родителю о готовности.
+	notifyUpgradeReady()
+	upgraded := make(chan struct{})
+	if cfg.Upgrade != nil {
+		go watchUpgrade(watchCtx, cfg.Upgrade, eps, usesTLS(specs), upgraded)
+	}
+
+	var workerErr, serveErr error
+	stopped := map[*endpoint]bool{}
 	select {
-	case err := <-errCh:
-		if err == http.ErrServerClosed {
-			return nil
-		}
-		return err
+	case res := <-errCh:
+		stopped[res.ep] = true
+		serveErr = res.error()
+	case workerErr = <-workers.errs:
+	case <-upgraded:
+		keepSockets(eps)
 	case <-ctx.Done():
 	}
 
+	// Drain: /readyz отвечает 503, балансировщик успевает снять под до закрытия соединений.
+	if len(stopped) == 0 && cfg.Readiness != nil {
+		cfg.Readiness.Drain()
+		if res, ok := drain(cfg.DrainTimeout, errCh); ok {
+			stopped[res.ep] = true
+			serveErr = res.error()
+		}
+	}
+
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
-	shutdownErr := srv.Shutdown(shutdownCtx)
-	if shutdownErr != nil && shutdownCtx.Err() == context.DeadlineExceeded {
-		_ = srv.Close()
+	cancelWorkers()
+	var shutdownErr error
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, ep := range eps {
+		if stopped[ep] {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := ep.srv.Shutdown(shutdownCtx)
+			if err != nil && shutdownCtx.Err() == context.DeadlineExceeded {
+				_ = ep.srv.Close()
+				return
+			}
+			if err != nil {
+				mu.Lock()
+				shutdownErr = errors.Join(shutdownErr, err)
+				mu.Unlock()
+			}
+		}()
 	}
+	wg.Wait()
+	waitErr := workers.wait(shutdownCtx)
 
-	err := <-errCh
-	if err == http.ErrServerClosed {
-		return nil
+	for range len(eps) - len(stopped) {
+		if err := (<-errCh).error(); err != nil && serveErr == nil {
+			serveErr = err
+		}
 	}
-	if shutdownErr != nil && shutdownCtx.Err() != context.DeadlineExceeded {
+	switch {
+	case workerErr != nil:
+		return workerErr
+	case serveErr != nil:
+		return serveErr
+	case shutdownErr != nil:
 		return shutdownErr
 	}
-	return err
+	return waitErr
+}
+
+// drain ждёт d, продолжая обслуживать запросы; прерывается, если listener упал.
+func drain(d time.Duration, errCh <-chan endpointErr) (endpointErr, bool) {
+	if d <= 0 {
+		return endpointErr{}, false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return endpointErr{}, false
+	case res := <-errCh:
+		return res, true
+	}
+}
+
+func usesTLS(specs []Listener) bool {
+	for _, l := range specs {
+		if l.TLS != nil {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultTLSReloadInterval — период проверки изменения файлов сертификатов.
+const DefaultTLSReloadInterval = 10 * time.Second
+
+var errTLSFiles = errors.New("app: tls requires CertFile and KeyFile")
+
+// TLSConfig описывает TLS и mTLS для Run.
+//
+// Сертификат, ключ и CA перечитываются при изменении файлов (опрос раз в ReloadInterval)
+// и по SIGHUP без перезапуска. Ошибка перечитывания не заменяет рабочий сертификат.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile включает mTLS: клиентский сертификат обязателен и проверяется по этому CA bundle.
+	ClientCAFile string
+	// ClientAuth переопределяет политику проверки клиента (по умолчанию RequireAndVerifyClientCert при ClientCAFile).
+	ClientAuth tls.ClientAuthType
+	// MinVersion — минимальная версия TLS (по умолчанию TLS 1.2).
+	MinVersion uint16
+	// CipherSuites ограничивает шифры TLS 1.2; nil — безопасные дефолты crypto/tls.
+	CipherSuites []uint16
+	// ReloadInterval — период опроса файлов; 0 — DefaultTLSReloadInterval, < 0 — только SIGHUP.
+	ReloadInterval time.Duration
+	// OnReloadError получает ошибки перечитывания; nil — ошибки игнорируются.
+	OnReloadError func(err error)
+}
+
+// PeerIdentity — проверенная идентичность клиента mTLS.
+type PeerIdentity struct {
+	CommonName  string
+	DNSNames    []string
+	URIs        []string // например SPIFFE ID
+	Certificate *x509.Certificate
+}
+
+// Peer возвращает идентичность клиента, если сертификат прошёл проверку по CA.
+func Peer(r *http.Request) (PeerIdentity, bool) {
+	if r == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return PeerIdentity{}, false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	id := PeerIdentity{
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		Certificate: cert,
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	return id, true
+}
+
+// tlsState — текущие сертификат и CA; заменяется целиком при reload.
+type tlsState struct {
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	mtimes   [3]time.Time
+}
+
+type certReloader struct {
+	cfg   TLSConfig
+	state atomic.Pointer[tlsState]
+}
+
+func newCertReloader(cfg TLSConfig) (*certReloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errTLSFiles
+	}
+	r := &certReloader{cfg: cfg}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) files() [3]string {
+	return [3]string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile}
+}
+
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("app: load tls key pair: %w", err)
+	}
+	st := &tlsState{cert: &cert, mtimes: r.mtimes()}
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("app: read client ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("app: client ca %s: no certificates found", r.cfg.ClientCAFile)
+		}
+		st.clientCA = pool
+	}
+	r.state.Store(st)
+	return nil
+}
+
+func (r *certReloader) mtimes() [3]time.Time {
+	var out [3]time.Time
+	for i, name := range r.files() {
+		if name == "" {
+			continue
+		}
+		if fi, err := os.Stat(name); err == nil {
+			out[i] = fi.ModTime()
+		}
+	}
+	return out
+}
+
+func (r *certReloader) changed() bool {
+	return r.mtimes() != r.state.Load().mtimes
+}
+
+// tlsConfig собирает *tls.Config, который на каждом handshake берёт текущее состояние.
+// nextProtos задаёт ALPN: конфиг из GetConfigForClient заменяет серверный целиком,
+// и протоколы, которые добавил бы ServeTLS, в него не попадают.
+func (r *certReloader) tlsConfig(nextProtos []string) *tls.Config {
+	minVersion := r.cfg.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	clientAuth := r.cfg.ClientAuth
+	if clientAuth == tls.NoClientCert && r.cfg.ClientCAFile != "" {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	base := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: r.cfg.CipherSuites,
+		ClientAuth:   clientAuth,
+		NextProtos:   nextProtos,
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		st := r.state.Load()
+		c := base.Clone()
+		c.GetConfigForClient = nil
+		c.Certificates = []tls.Certificate{*st.cert}
+		c.ClientCAs = st.clientCA
+		return c, nil
+	}
+	base.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return r.state.Load().cert, nil
+	}
+	return base
+}
+
+// watch перечитывает файлы по SIGHUP и при изменении mtime до отмены ctx.
+func (r *certReloader) watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	interval := r.cfg.ReloadInterval
+	if interval == 0 {
+		interval = DefaultTLSReloadInterval
+	}
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-tick:
+			if !r.changed() {
+				continue
+			}
+		}
+		if err := r.reload(); err != nil && r.cfg.OnReloadError != nil {
+			r.cfg.OnReloadError(err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	tls  tls.Certificate
+}
+
+func issueCert(t *testing.T, cn string, serial int64, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("не удалось создать ключ: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         isCA,
+
+		BasicConstraintsValid: true,
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("не удалось выпустить сертификат: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("некорректная пара: %v", err)
+	}
+	return &testCert{cert: cert, key: key, pem: append(certPEM, keyPEM...), tls: pair}
+}
+
+func writeCert(t *testing.T, dir string, c *testCert, mtime time.Time) (certFile, keyFile string) {
+	t.Helper()
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	for _, name := range []string{certFile, keyFile} {
+		if err := os.WriteFile(name, c.pem, 0o600); err != nil {
+			t.Fatalf("не удалось записать %s: %v", name, err)
+		}
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatalf("не удалось обновить mtime: %v", err)
+		}
+	}
+	return certFile, keyFile
+}
+
+func TestRunMutualTLSAndReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCert(t, "test-ca", 1, nil, true)
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600); err != nil {
+		t.Fatalf("не удалось записать CA: %v", err)
+	}
+	certFile, keyFile := writeCert(t, dir, issueCert(t, "server-1", 2, ca, false), time.Now().Add(-time.Minute))
+	client := issueCert(t, "billing", 3, ca, false)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+	cfg.TLS = &TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ReloadInterval: 10 * time.Millisecond}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer, ok := Peer(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(peer.CommonName))
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, h, ln)
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	url := "https://" + ln.Addr().String() + "/"
+	get := func(certs []tls.Certificate) (*http.Response, error) {
+		c := &http.Client{Timeout: time.Second, Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+		return c.Get(url)
+	}
+
+	resp, err := get([]tls.Certificate{client.tls})
+	if err != nil {
+		t.Fatalf("mTLS запрос не прошёл: %v", err)
+	}
+	body := make([]byte, 16)
+	n, _ := resp.Body.Read(body)
+	_ = resp.Body.Close()
+	if string(body[:n]) != "billing" {
+		t.Fatalf("ожидали peer=billing, получили %q", body[:n])
+	}
+	if resp.TLS.PeerCertificates[0].Subject.CommonName != "server-1" {
+		t.Fatalf("неожиданный серверный сертификат %q", resp.TLS.PeerCertificates[0].Subject.CommonName)
+	}
+
+	if _, err := get(nil); err == nil {
+		t.Fatalf("запрос без клиентского сертификата должен отклоняться")
+	}
+
+	writeCert(t, dir, issueCert(t, "server-2", 4, ca, false), time.Now())
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := get([]tls.Certificate{client.tls})
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.TLS.PeerCertificates[0].Subject.CommonName == "server-2" {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("сертификат не перечитан")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cancel()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+}
+
+func TestRunTLSInvalidFiles(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.TLS = &TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"}
+	if err := runWithListener(context.Background(), cfg, http.NotFoundHandler(), ln); err == nil {
+		t.Fatalf("ожидали ошибку загрузки сертификата")
+	}
+
+	cfg.TLS = &TLSConfig{}
+	if err := runWithListener(context.Background(), cfg, http.NotFoundHandler(), ln); err != errTLSFiles {
+		t.Fatalf("ожидали errTLSFiles, получили %v", err)
+	}
+}
+
+func TestRunTLSServesHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCert(t, "test-ca", 1, nil, true)
+	certFile, keyFile := writeCert(t, dir, issueCert(t, "server", 2, ca, false), time.Now())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("не ожидали ошибку listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+	cfg.TLS = &TLSConfig{CertFile: certFile, KeyFile: keyFile}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithListener(ctx, cfg, h, ln)
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(tr *http.Transport) *http.Response {
+		t.Helper()
+		c := &http.Client{Timeout: time.Second, Transport: tr}
+		defer tr.CloseIdleConnections()
+		var resp *http.Response
+		var err error
+		deadline := time.Now().Add(time.Second)
+		for {
+			if resp, err = c.Get("https://" + ln.Addr().String() + "/"); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("TLS запрос не прошёл: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	resp := get(&http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}, ForceAttemptHTTP2: true})
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("ожидали HTTP/2 по ALPN, получили %s", resp.Proto)
+	}
+	resp = get(&http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, NextProtos: []string{"http/1.1"}}})
+	if resp.ProtoMajor != 1 {
+		t.Fatalf("ожидали HTTP/1.1 для клиента без h2, получили %s", resp.Proto)
+	}
+
+	cancel()
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("не ожидали ошибку Run: %v", err)
+	}
+}
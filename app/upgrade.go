@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUpgradeReadyTimeout — сколько ждать готовности нового процесса.
+const DefaultUpgradeReadyTimeout = 30 * time.Second
+
+// Переменные окружения, через которые новый процесс получает listeners.
+const (
+	envUpgradeFDs     = "NOPE_UPGRADE_FDS"
+	envUpgradeReadyFD = "NOPE_UPGRADE_READY_FD"
+)
+
+var (
+	// ErrUpgradeUnsupported возвращается, если платформа не поддерживает передачу listeners.
+	ErrUpgradeUnsupported = errors.New("app: binary upgrade is not supported on this platform")
+	errUpgradeNotReady    = errors.New("app: upgraded process exited before ready")
+)
+
+// UpgradeConfig включает обновление бинаря без простоя.
+//
+// По сигналу (SIGUSR2; SIGHUP — если ни один listener не использует TLS, иначе SIGHUP
+// перечитывает сертификаты) Run запускает новый бинарь и передаёт ему listening sockets.
+// Новый процесс сообщает о готовности после старта всех listeners, после чего текущий
+// проходит обычный drain и Shutdown с ShutdownTimeout. Если новый процесс не стал готов,
+// текущий продолжает работу, а ошибка уходит в OnError.
+type UpgradeConfig struct {
+	// Path — путь к новому бинарю; пусто — os.Executable().
+	Path string
+	// Args — аргументы нового процесса; nil — os.Args[1:].
+	Args []string
+	// ReadyTimeout — ожидание готовности; 0 — DefaultUpgradeReadyTimeout.
+	ReadyTimeout time.Duration
+	// OnError получает ошибки неудачного обновления.
+	OnError func(err error)
+}
+
+// inherited — listeners, переданные родительским процессом при обновлении.
+var (
+	inheritOnce sync.Once
+	inheritMu   sync.Mutex
+	inheritLns  map[string]net.Listener
+	readyFile   *os.File
+)
+
+func loadInherited() {
+	defer func() {
+		_ = os.Unsetenv(envUpgradeFDs)
+		_ = os.Unsetenv(envUpgradeReadyFD)
+	}()
+	names := os.Getenv(envUpgradeFDs)
+	if names == "" {
+		return
+	}
+	inheritLns = map[string]net.Listener{}
+	for i, name := range strings.Split(names, ":") {
+		f := os.NewFile(uintptr(listenFDsStart+i), name)
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err == nil {
+			inheritLns[name] = ln
+		}
+	}
+	if fd, err := strconv.Atoi(os.Getenv(envUpgradeReadyFD)); err == nil {
+		readyFile = os.NewFile(uintptr(fd), "upgrade-ready")
+	}
+}
+
+// listenNamed забирает listener от родителя при обновлении или открывает новый по addr.
+func listenNamed(name, addr string, mode os.FileMode) (net.Listener, error) {
+	inheritOnce.Do(loadInherited)
+	inheritMu.Lock()
+	ln, ok := inheritLns[name]
+	delete(inheritLns, name)
+	inheritMu.Unlock()
+	if ok {
+		return ln, nil
+	}
+	return listen(addr, mode)
+}
+
+// notifyUpgradeReady сообщает родителю, что новый процесс обслуживает listeners.
+func notifyUpgradeReady() {
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+	if readyFile == nil {
+		return
+	}
+	_, _ = readyFile.Write([]byte{1})
+	_ = readyFile.Close()
+	readyFile = nil
+}
+
+// watchUpgrade ждёт сигнал обновления и закрывает upgraded после готовности нового процесса.
+func watchUpgrade(ctx context.Context, cfg *UpgradeConfig, eps []*endpoint, tlsInUse bool, upgraded chan<- struct{}) {
+	sigs := upgradeSignals(tlsInUse)
+	if len(sigs) == 0 {
+		reportUpgradeErr(cfg, ErrUpgradeUnsupported)
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+		}
+		if err := startUpgrade(ctx, cfg, eps); err != nil {
+			reportUpgradeErr(cfg, err)
+			continue
+		}
+		close(upgraded)
+		return
+	}
+}
+
+func reportUpgradeErr(cfg *UpgradeConfig, err error) {
+	if cfg.OnError != nil {
+		cfg.OnError(err)
+	}
+}
+
+// startUpgrade запускает новый бинарь с копиями listening sockets и ждёт его готовности.
+func startUpgrade(ctx context.Context, cfg *UpgradeConfig, eps []*endpoint) error {
+	path := cfg.Path
+	if path == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("app: upgrade: %w", err)
+		}
+		path = exe
+	}
+	args := cfg.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	names := make([]string, 0, len(eps))
+	for _, ep := range eps {
+		fl, ok := ep.ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("app: upgrade: listener %s cannot be passed to a child process", ep.name)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return fmt.Errorf("app: upgrade: listener %s: %w", ep.name, err)
+		}
+		files = append(files, f)
+		names = append(names, ep.name)
+	}
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("app: upgrade: %w", err)
+	}
+	defer readyR.Close()
+	files = append(files, readyW)
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		envUpgradeFDs+"="+strings.Join(names, ":"),
+		envUpgradeReadyFD+"="+strconv.Itoa(listenFDsStart+len(names)),
+	)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("app: upgrade: %w", err)
+	}
+	// Свою копию write‑конца закрываем, чтобы выход child дал EOF.
+	_ = readyW.Close()
+	files = files[:len(files)-1]
+
+	timeout := cfg.ReadyTimeout
+	if timeout <= 0 {
+		timeout = DefaultUpgradeReadyTimeout
+	}
+	ready := make(chan error, 1)
+	go func() {
+		var b [1]byte
+		_, err := io.ReadFull(readyR, b[:])
+		ready <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-ready:
+		if err == nil {
+			go func() { _ = cmd.Wait() }()
+			return nil
+		}
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return errUpgradeNotReady
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+	return fmt.Errorf("app: upgrade: child not ready within %s", timeout)
+}
+
+// keepSockets не даёт Close удалить файлы Unix sockets, которые теперь обслуживает новый процесс.
+func keepSockets(eps []*endpoint) {
+	for _, ep := range eps {
+		if ul, ok := ep.ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package app
+
+import "os"
+
+func upgradeSignals(bool) []os.Signal {
+	return nil
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestUpgradeHandsOverListener(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("тест обновления бинаря выполняется на linux")
+	}
+	dir := t.TempDir()
+	addrFile := filepath.Join(dir, "addr")
+	logFile, err := os.Create(filepath.Join(dir, "log"))
+	if err != nil {
+		t.Fatalf("не удалось создать лог: %v", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestUpgradeHelper$")
+	cmd.Env = append(os.Environ(), "NOPE_TEST_UPGRADE_CHILD=1", "NOPE_TEST_ADDR_FILE="+addrFile)
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("не удалось запустить процесс: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	var addr string
+	deadline := time.Now().Add(5 * time.Second)
+	for addr == "" {
+		if b, err := os.ReadFile(addrFile); err == nil && len(b) > 0 {
+			addr = string(b)
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("процесс не сообщил адрес")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	client := &http.Client{Timeout: time.Second}
+	pidOf := func() int {
+		r, err := client.Get("http://" + addr + "/")
+		if err != nil {
+			return 0
+		}
+		defer r.Body.Close()
+		b, _ := io.ReadAll(r.Body)
+		pid, _ := strconv.Atoi(string(b))
+		return pid
+	}
+
+	first := pidOf()
+	if first != cmd.Process.Pid {
+		t.Fatalf("ожидали pid %d, получили %d", cmd.Process.Pid, first)
+	}
+	if err := cmd.Process.Signal(syscall.SIGUSR2); err != nil {
+		t.Fatalf("не удалось отправить SIGUSR2: %v", err)
+	}
+
+	var next int
+	deadline = time.Now().Add(10 * time.Second)
+	for {
+		if pid := pidOf(); pid != 0 && pid != first {
+			next = pid
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("новый процесс не принял listener")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Cleanup(func() {
+		_ = syscall.Kill(next, syscall.SIGKILL)
+	})
+
+	select {
+	case err := <-exited:
+		if err != nil {
+			b, _ := os.ReadFile(logFile.Name())
+			t.Fatalf("старый процесс завершился с ошибкой: %v\n%s", err, b)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("старый процесс не завершился после обновления")
+	}
+	if pid := pidOf(); pid != next {
+		t.Fatalf("после выхода родителя ожидали pid %d, получили %d", next, pid)
+	}
+
+	if err := syscall.Kill(next, syscall.SIGTERM); err != nil {
+		t.Fatalf("не удалось остановить новый процесс: %v", err)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for syscall.Kill(next, 0) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("новый процесс не остановился по SIGTERM")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// TestUpgradeHelper — сервер в дочернем процессе TestUpgradeHandsOverListener.
+// После SIGUSR2 он же запускается как новый бинарь и получает listener.
+func TestUpgradeHelper(t *testing.T) {
+	if os.Getenv("NOPE_TEST_UPGRADE_CHILD") != "1" {
+		t.Skip("helper process")
+	}
+	_, inherited := os.LookupEnv(envUpgradeFDs)
+
+	cfg := DefaultConfig()
+	cfg.Addr = "127.0.0.1:0"
+	cfg.ShutdownTimeout = time.Second
+	cfg.Upgrade = &UpgradeConfig{
+		Args:         []string{"-test.run=^TestUpgradeHelper$"},
+		ReadyTimeout: 5 * time.Second,
+		OnError: func(err error) {
+			t.Errorf("upgrade: %v", err)
+		},
+	}
+	ln, err := Listen(cfg)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if !inherited {
+		addr := ln.Addr().String()
+		if err := os.WriteFile(os.Getenv("NOPE_TEST_ADDR_FILE"), []byte(addr), 0o600); err != nil {
+			t.Fatalf("не удалось записать адрес: %v", err)
+		}
+	} else if !strings.HasPrefix(ln.Addr().String(), "127.0.0.1:") {
+		t.Fatalf("ожидали унаследованный TCP listener, получили %s", ln.Addr())
+	}
+
+	pid := strconv.Itoa(os.Getpid())
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(pid))
+	})
+	if err := RunListener(context.Background(), cfg, h, ln); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
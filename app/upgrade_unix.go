@@ -0,0 +1,16 @@
+//go:build unix
+
+package app
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSignals — SIGUSR2 всегда; SIGHUP, если он не занят перечитыванием TLS.
+func upgradeSignals(tlsInUse bool) []os.Signal {
+	if tlsInUse {
+		return []os.Signal{syscall.SIGUSR2}
+	}
+	return []os.Signal{syscall.SIGUSR2, syscall.SIGHUP}
+}
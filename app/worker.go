@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrWorkersTimeout возвращается, если workers не остановились за ShutdownTimeout.
+var ErrWorkersTimeout = errors.New("app: workers did not stop within shutdown timeout")
+
+// Worker — фоновая задача, которая живёт вместе с HTTP-сервером
+// (consumer очереди, ticker, прогрев кэша).
+//
+// Run должен завершиться после отмены ctx. Ошибка, кроме вызванной отменой ctx,
+// останавливает всё приложение; nil до остановки просто завершает worker.
+type Worker interface {
+	Run(ctx context.Context) error
+}
+
+// WorkerFunc адаптирует функцию к Worker.
+type WorkerFunc func(ctx context.Context) error
+
+// Run вызывает f(ctx).
+func (f WorkerFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+type workerGroup struct {
+	wg   sync.WaitGroup
+	errs chan error
+	done chan struct{}
+}
+
+// startWorkers запускает workers; первая ошибка доступна в errs.
+func startWorkers(ctx context.Context, workers []Worker) *workerGroup {
+	g := &workerGroup{errs: make(chan error, len(workers)), done: make(chan struct{})}
+	for _, w := range workers {
+		if w == nil {
+			continue
+		}
+		g.wg.Add(1)
+		go func() {
+			defer g.wg.Done()
+			if err := runWorker(ctx, w); err != nil {
+				if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+					return
+				}
+				g.errs <- err
+			}
+		}()
+	}
+	go func() {
+		g.wg.Wait()
+		close(g.done)
+	}()
+	return g
+}
+
+func runWorker(ctx context.Context, w Worker) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("app: worker panic: %v", rec)
+		}
+	}()
+	return w.Run(ctx)
+}
+
+// wait ждёт завершения workers до отмены ctx.
+func (g *workerGroup) wait(ctx context.Context) error {
+	select {
+	case <-g.done:
+		return nil
+	case <-ctx.Done():
+		return ErrWorkersTimeout
+	}
+}
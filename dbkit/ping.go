@@ -0,0 +1,17 @@
+package dbkit
+
+import "context"
+
+// Pinger описывает соединение с проверкой доступности (*sql.DB, *sql.Conn).
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck возвращает проверку готовности для app.Readiness:
+//
+//	ready.Add("db", dbkit.PingCheck(db), time.Second)
+func PingCheck(p Pinger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return p.PingContext(ctx)
+	}
+}
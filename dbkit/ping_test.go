@@ -0,0 +1,34 @@
+package dbkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPingCheck(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("не удалось создать sqlmock: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	down := errors.New("down")
+	mock.ExpectPing()
+	mock.ExpectPing().WillReturnError(down)
+
+	check := PingCheck(db)
+	if err := check(context.Background()); err != nil {
+		t.Fatalf("ожидали успешный ping, получили %v", err)
+	}
+	if err := check(context.Background()); !errors.Is(err, down) {
+		t.Fatalf("ожидали ошибку ping, получили %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("ожидания не выполнены: %v", err)
+	}
+}
@@ -9,4 +9,12 @@ const (
 	CodeTimeout = "timeout"
 	// MsgTimeout — безопасное сообщение для ошибки таймаута.
 	MsgTimeout = "request timed out"
+	// CodeNotFound — стабильный код для ненайденного маршрута.
+	CodeNotFound = "not_found"
+	// MsgNotFound — безопасное сообщение для ненайденного маршрута.
+	MsgNotFound = "not found"
+	// CodeMethodNotAllowed — стабильный код для неподдерживаемого метода.
+	CodeMethodNotAllowed = "method_not_allowed"
+	// MsgMethodNotAllowed — безопасное сообщение для неподдерживаемого метода.
+	MsgMethodNotAllowed = "method not allowed"
 )
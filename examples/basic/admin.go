@@ -15,10 +15,18 @@ type adminStatsResponse struct {
 
 func adminRouter(startedAt time.Time) http.Handler {
 	r := router.New()
-	r.GET("/stats", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	r.With(noStore).GET("/stats", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		uptime := int64(time.Since(startedAt).Seconds())
 		resp := adminStatsResponse{UptimeSec: uptime, Version: "dev"}
 		json.WriteJSON(w, http.StatusOK, resp)
 	}))
 	return r
 }
+
+// noStore запрещает кеширование ответов admin-зоны.
+func noStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
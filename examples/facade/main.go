@@ -3,11 +3,26 @@ package main
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sejta/nope/server"
 )
 
+type greetRequest struct {
+	Name  string `path:"name"`
+	Times int    `query:"times"`
+}
+
+type greetResponse struct {
+	Message string `json:"message"`
+}
+
+func greet(ctx context.Context, req greetRequest) (greetResponse, error) {
+	times := max(req.Times, 1)
+	return greetResponse{Message: strings.Repeat("hello, "+req.Name+"! ", times)}, nil
+}
+
 func main() {
 	srv := server.NewWithPreset(":8080", server.PresetDefault)
 	srv.EnableHealth()
@@ -26,6 +41,7 @@ func main() {
 	api.GET("/time", func(ctx context.Context, r *http.Request) (any, error) {
 		return map[string]string{"now": time.Now().UTC().Format(time.RFC3339)}, nil
 	})
+	server.Handle(api, http.MethodGet, "/greet/:name", greet)
 
 	_ = srv.Run()
 }
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	apperrors "github.com/sejta/nope/errors"
+	jsonkit "github.com/sejta/nope/json"
+)
+
+// MaxBodyBytes ограничивает размер тела запроса.
+// Запрос с Content-Length больше лимита получает 413 body_too_large сразу,
+// иначе тело оборачивается в http.MaxBytesReader и ошибку возвращает чтение.
+func MaxBodyBytes(n int64) func(next http.Handler) http.Handler {
+	if n <= 0 {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				err := apperrors.E(http.StatusRequestEntityTooLarge, jsonkit.CodeBodyTooLarge, jsonkit.MsgBodyTooLarge)
+				apperrors.WriteError(w, r, err)
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
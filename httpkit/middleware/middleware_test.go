@@ -3,7 +3,9 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -291,6 +293,22 @@ func TestTimeoutErrorIgnoresCanceledContext(t *testing.T) {
 	}
 }
 
+func TestTimeoutErrorRepanicsInCaller(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	var recovered any
+	func() {
+		defer func() {
+			recovered = recover()
+		}()
+		TimeoutError(DefaultTimeoutError)(inner).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	if recovered != "boom" {
+		t.Fatalf("ожидали panic в вызывающей горутине, получили %v", recovered)
+	}
+}
+
 func TestReqIDNilContext(t *testing.T) {
 	if ReqID(context.TODO()) != "" {
 		t.Fatalf("ожидали пустой request id для пустого контекста")
@@ -353,3 +371,47 @@ func (c *manualDeadlineContext) trigger(err error) {
 		close(c.done)
 	})
 }
+
+func TestMaxBodyBytesRejectsContentLength(t *testing.T) {
+	called := false
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789"))
+
+	MaxBodyBytes(4)(inner).ServeHTTP(w, req)
+
+	if called {
+		t.Fatalf("handler не должен вызываться")
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("ожидали 413, получили %d", w.Code)
+	}
+	var payload errorPayload
+	if err := json.NewDecoder(w.Body).Decode(&payload); err != nil {
+		t.Fatalf("не удалось распарсить JSON: %v", err)
+	}
+	if payload.Error.Code != "body_too_large" {
+		t.Fatalf("ожидали code=body_too_large, получили %q", payload.Error.Code)
+	}
+}
+
+func TestMaxBodyBytesLimitsStreamingBody(t *testing.T) {
+	var readErr error
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789"))
+	req.ContentLength = -1
+
+	MaxBodyBytes(4)(inner).ServeHTTP(w, req)
+
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Fatalf("ожидали MaxBytesError, получили %v", readErr)
+	}
+}
@@ -12,6 +12,10 @@ import (
 )
 
 // TimeoutError пишет ответ при превышении deadline, если ответ ещё не начат.
+//
+// Handler выполняется в отдельной горутине; его panic передаётся обратно и повторяется
+// в горутине запроса, чтобы её видели внешние Recover и hooks. Panic после таймаута
+// (ответ уже записан) подавляется.
 func TimeoutError(write func(w http.ResponseWriter, r *http.Request)) func(http.Handler) http.Handler {
 	if write == nil {
 		return func(next http.Handler) http.Handler {
@@ -22,7 +26,13 @@ func TimeoutError(write func(w http.ResponseWriter, r *http.Request)) func(http.
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			tw := &timeoutWriter{ResponseWriter: w}
 			done := make(chan struct{})
+			panicked := make(chan any, 1)
 			go func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						panicked <- rec
+					}
+				}()
 				next.ServeHTTP(tw, r)
 				close(done)
 			}()
@@ -30,6 +40,8 @@ func TimeoutError(write func(w http.ResponseWriter, r *http.Request)) func(http.
 			select {
 			case <-done:
 				return
+			case rec := <-panicked:
+				panic(rec)
 			case <-r.Context().Done():
 				if r.Context().Err() != context.DeadlineExceeded {
 					return
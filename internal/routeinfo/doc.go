@@ -0,0 +1,2 @@
+// Package routeinfo передаёт сведения о совпавшем маршруте от router к app hooks.
+package routeinfo
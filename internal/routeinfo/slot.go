@@ -0,0 +1,72 @@
+package routeinfo
+
+import (
+	"context"
+	"sync"
+)
+
+type slotKey struct{}
+
+// Slot хранит pattern совпавшего маршрута для внешних обёрток.
+type Slot struct {
+	mu        sync.Mutex
+	pattern   string
+	unmatched bool
+}
+
+// WithSlot кладёт новый Slot в контекст.
+func WithSlot(ctx context.Context) (context.Context, *Slot) {
+	slot := &Slot{}
+	return context.WithValue(ctx, slotKey{}, slot), slot
+}
+
+// FromContext возвращает Slot из контекста, если он есть.
+func FromContext(ctx context.Context) *Slot {
+	if ctx == nil {
+		return nil
+	}
+	slot, _ := ctx.Value(slotKey{}).(*Slot)
+	return slot
+}
+
+// SetPattern сохраняет pattern маршрута.
+func (s *Slot) SetPattern(pattern string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.pattern = pattern
+	s.unmatched = false
+	s.mu.Unlock()
+}
+
+// SetUnmatched отмечает, что router обработал запрос без совпадения маршрута.
+func (s *Slot) SetUnmatched() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.pattern = ""
+	s.unmatched = true
+	s.mu.Unlock()
+}
+
+// Pattern возвращает сохранённый pattern маршрута.
+func (s *Slot) Pattern() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pattern
+}
+
+// Unmatched сообщает, что router обработал запрос без совпадения маршрута.
+func (s *Slot) Unmatched() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unmatched
+}
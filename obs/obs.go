@@ -18,6 +18,7 @@ type Logger interface {
 }
 
 // Metrics описывает минимальный контракт метрик запросов.
+// В path передаётся шаблон маршрута, если он известен, иначе фактический путь.
 type Metrics interface {
 	ObserveRequest(method, path string, status int, dur time.Duration)
 }
@@ -26,6 +27,7 @@ type Metrics interface {
 type RequestEndEvent struct {
 	Method  string
 	Path    string
+	Pattern string
 	Status  int
 	Bytes   int
 	Dur     time.Duration
@@ -35,10 +37,11 @@ type RequestEndEvent struct {
 
 // PanicEvent содержит данные о panic.
 type PanicEvent struct {
-	Method string
-	Path   string
-	ReqID  string
-	Value  any
+	Method  string
+	Path    string
+	Pattern string
+	ReqID   string
+	Value   any
 }
 
 // NewHooks создаёт набор hooks для логирования и метрик.
@@ -57,6 +60,7 @@ func NewHooks(logger Logger, metrics Metrics) app.Hooks {
 			event := RequestEndEvent{
 				Method:  info.Method,
 				Path:    info.Path,
+				Pattern: info.Pattern,
 				Status:  res.Status,
 				Bytes:   meta.bytesValue(),
 				Dur:     duration(meta, res.Duration),
@@ -67,7 +71,7 @@ func NewHooks(logger Logger, metrics Metrics) app.Hooks {
 				logger.LogRequestEnd(ctx, event)
 			}
 			if metrics != nil {
-				metrics.ObserveRequest(info.Method, info.Path, res.Status, event.Dur)
+				metrics.ObserveRequest(info.Method, metricsPath(info), res.Status, event.Dur)
 			}
 		},
 		OnPanic: func(ctx context.Context, info app.RequestInfo, recovered any) {
@@ -76,10 +80,11 @@ func NewHooks(logger Logger, metrics Metrics) app.Hooks {
 			}
 			meta := getReqMeta(ctx)
 			logger.LogPanic(ctx, PanicEvent{
-				Method: info.Method,
-				Path:   info.Path,
-				ReqID:  meta.reqIDValue(),
-				Value:  recovered,
+				Method:  info.Method,
+				Path:    info.Path,
+				Pattern: info.Pattern,
+				ReqID:   meta.reqIDValue(),
+				Value:   recovered,
 			})
 		},
 	}
@@ -94,11 +99,25 @@ func NewMetricsHook(m Metrics) app.Hooks {
 		OnRequestEnd: func(ctx context.Context, info app.RequestInfo, res app.ResponseInfo) {
 			meta := getReqMeta(ctx)
 			dur := duration(meta, res.Duration)
-			m.ObserveRequest(info.Method, info.Path, res.Status, dur)
+			m.ObserveRequest(info.Method, metricsPath(info), res.Status, dur)
 		},
 	}
 }
 
+// UnmatchedRoute — метка path в метриках для запросов, которые router обработал
+// без совпадения маршрута, чтобы сканеры путей не раздували кардинальность.
+const UnmatchedRoute = "unmatched"
+
+func metricsPath(info app.RequestInfo) string {
+	if info.Pattern != "" {
+		return info.Pattern
+	}
+	if info.Unmatched {
+		return UnmatchedRoute
+	}
+	return info.Path
+}
+
 func errKind(err error) string {
 	if err == nil {
 		return ""
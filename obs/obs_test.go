@@ -144,3 +144,52 @@ func spinUntilTick() {
 	for time.Since(start) == 0 {
 	}
 }
+
+type testMetrics struct {
+	path string
+}
+
+func (m *testMetrics) ObserveRequest(method, path string, status int, dur time.Duration) {
+	m.path = path
+}
+
+func TestObsHooks_PatternForMetrics(t *testing.T) {
+	logger := &testLogger{}
+	metrics := &testMetrics{}
+	hooks := NewHooks(logger, metrics)
+
+	ctx := hooks.OnRequestStart(context.Background(), app.RequestInfo{Method: http.MethodGet, Path: "/posts/1"})
+	info := app.RequestInfo{Method: http.MethodGet, Path: "/posts/1", Pattern: "/posts/:id"}
+	hooks.OnRequestEnd(ctx, info, app.ResponseInfo{Status: http.StatusOK, Duration: time.Millisecond})
+
+	if metrics.path != "/posts/:id" {
+		t.Fatalf("ожидали path=%q в метриках, получили %q", "/posts/:id", metrics.path)
+	}
+	if logger.lastEnd.Pattern != "/posts/:id" || logger.lastEnd.Path != "/posts/1" {
+		t.Fatalf("ожидали pattern и path в событии, получили %+v", logger.lastEnd)
+	}
+}
+
+func TestObsHooks_MetricsFallbackToPath(t *testing.T) {
+	metrics := &testMetrics{}
+	hooks := NewMetricsHook(metrics)
+
+	info := app.RequestInfo{Method: http.MethodGet, Path: "/raw"}
+	hooks.OnRequestEnd(context.Background(), info, app.ResponseInfo{Status: http.StatusOK})
+
+	if metrics.path != "/raw" {
+		t.Fatalf("ожидали path=%q, получили %q", "/raw", metrics.path)
+	}
+}
+
+func TestObsHooks_MetricsUnmatchedRoute(t *testing.T) {
+	metrics := &testMetrics{}
+	hooks := NewMetricsHook(metrics)
+
+	info := app.RequestInfo{Method: http.MethodGet, Path: "/wp-admin/login.php", Unmatched: true}
+	hooks.OnRequestEnd(context.Background(), info, app.ResponseInfo{Status: http.StatusNotFound})
+
+	if metrics.path != UnmatchedRoute {
+		t.Fatalf("ожидали path=%q, получили %q", UnmatchedRoute, metrics.path)
+	}
+}
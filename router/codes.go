@@ -0,0 +1,8 @@
+package router
+
+const (
+	// CodeInvalidParam — код ошибки некорректного параметра пути.
+	CodeInvalidParam = "invalid_param"
+	// MsgInvalidParam — сообщение об ошибке некорректного параметра пути.
+	MsgInvalidParam = "invalid path parameter"
+)
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// constraint ограничивает значение :param сегмента.
+type constraint struct {
+	raw   string
+	match func(string) bool
+}
+
+var builtinConstraints = map[string]func(string) bool{
+	"int":  isInt,
+	"uint": isUint,
+	"uuid": isUUID,
+}
+
+// parseParam разбирает сегмент вида :name или :name<constraint>.
+func parseParam(seg string) (string, *constraint) {
+	body := seg[1:]
+	open := strings.IndexByte(body, '<')
+	if open == -1 {
+		if body == "" {
+			panic("router: empty param name")
+		}
+		return body, nil
+	}
+	name := body[:open]
+	if name == "" {
+		panic("router: empty param name")
+	}
+	if !strings.HasSuffix(body, ">") || len(body) == open+2 {
+		panic("router: invalid param constraint")
+	}
+	raw := body[open+1 : len(body)-1]
+	if fn, ok := builtinConstraints[raw]; ok {
+		return name, &constraint{raw: raw, match: fn}
+	}
+	re, err := regexp.Compile("^(?:" + raw + ")$")
+	if err != nil {
+		panic("router: invalid param constraint")
+	}
+	return name, &constraint{raw: raw, match: re.MatchString}
+}
+
+func (c *constraint) rawValue() string {
+	if c == nil {
+		return ""
+	}
+	return c.raw
+}
+
+func (c *constraint) allows(value string) bool {
+	return c == nil || c.match(value)
+}
+
+// isInt и isUint сначала проверяют цифры, чтобы несовпадение не аллоцировало ошибку strconv.
+func isInt(s string) bool {
+	digits := s
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	if !isDigits(digits) {
+		return false
+	}
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func isUint(s string) bool {
+	if !isDigits(s) {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
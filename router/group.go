@@ -0,0 +1,163 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Middleware описывает HTTP middleware в формате net/http.
+type Middleware func(http.Handler) http.Handler
+
+// Group регистрирует маршруты в Router с общим prefix и middleware.
+//
+// Middleware оборачивают handler при регистрации и выполняются после матчинга,
+// поэтому в них доступны Param, Params и Pattern. Первый middleware — внешний.
+type Group struct {
+	r      *Router
+	prefix string
+	mws    []Middleware
+}
+
+// With возвращает Group без prefix с указанными middleware.
+func (r *Router) With(mw ...Middleware) *Group {
+	return (&Group{r: r}).With(mw...)
+}
+
+// Group вызывает fn с группой маршрутов под prefix.
+func (r *Router) Group(prefix string, fn func(g *Group)) {
+	(&Group{r: r}).Group(prefix, fn)
+}
+
+// With возвращает дочернюю Group с теми же prefix и middleware, дополненными mw.
+func (g *Group) With(mw ...Middleware) *Group {
+	child := &Group{r: g.r, prefix: g.prefix, mws: append([]Middleware(nil), g.mws...)}
+	child.Use(mw...)
+	return child
+}
+
+// Group вызывает fn с дочерней группой: prefix дописывается, middleware наследуются.
+func (g *Group) Group(prefix string, fn func(g *Group)) {
+	if prefix == "" || !strings.HasPrefix(prefix, "/") {
+		panic("router: prefix must start with /")
+	}
+	if prefix != "/" && strings.HasSuffix(prefix, "/") {
+		panic("router: prefix must not end with /")
+	}
+	child := g.With()
+	if prefix != "/" {
+		child.prefix = joinPattern(g.prefix, prefix)
+	}
+	if fn != nil {
+		fn(child)
+	}
+}
+
+// Use добавляет middleware для маршрутов, зарегистрированных в группе после вызова.
+func (g *Group) Use(mw ...Middleware) {
+	for _, one := range mw {
+		if one == nil {
+			continue
+		}
+		g.mws = append(g.mws, one)
+	}
+}
+
+// Handle регистрирует handler с prefix и middleware группы.
+func (g *Group) Handle(method, pattern string, h http.Handler) {
+	g.r.Handle(method, g.path(pattern), g.wrap(h))
+}
+
+// HandleNamed регистрирует именованный маршрут с prefix и middleware группы.
+func (g *Group) HandleNamed(name, method, pattern string, h http.Handler) {
+	g.r.HandleNamed(name, method, g.path(pattern), g.wrap(h))
+}
+
+// HandleFunc регистрирует handler func с prefix и middleware группы.
+func (g *Group) HandleFunc(method, pattern string, fn http.HandlerFunc) {
+	g.Handle(method, pattern, fn)
+}
+
+// GET регистрирует handler на GET.
+func (g *Group) GET(pattern string, h http.Handler) {
+	g.Handle(http.MethodGet, pattern, h)
+}
+
+// POST регистрирует handler на POST.
+func (g *Group) POST(pattern string, h http.Handler) {
+	g.Handle(http.MethodPost, pattern, h)
+}
+
+// PUT регистрирует handler на PUT.
+func (g *Group) PUT(pattern string, h http.Handler) {
+	g.Handle(http.MethodPut, pattern, h)
+}
+
+// PATCH регистрирует handler на PATCH.
+func (g *Group) PATCH(pattern string, h http.Handler) {
+	g.Handle(http.MethodPatch, pattern, h)
+}
+
+// DELETE регистрирует handler на DELETE.
+func (g *Group) DELETE(pattern string, h http.Handler) {
+	g.Handle(http.MethodDelete, pattern, h)
+}
+
+// HEAD регистрирует handler на HEAD.
+func (g *Group) HEAD(pattern string, h http.Handler) {
+	g.Handle(http.MethodHead, pattern, h)
+}
+
+// OPTIONS регистрирует handler на OPTIONS.
+func (g *Group) OPTIONS(pattern string, h http.Handler) {
+	g.Handle(http.MethodOptions, pattern, h)
+}
+
+// Mount монтирует под‑хендлер на prefix группы; middleware оборачивают sub‑handler.
+func (g *Group) Mount(prefix string, h http.Handler) {
+	if prefix == "/" && g.prefix != "" {
+		prefix = g.prefix
+	} else {
+		prefix = joinPattern(g.prefix, prefix)
+	}
+	g.r.Mount(prefix, g.wrap(h))
+}
+
+func (g *Group) path(pattern string) string {
+	if g.prefix == "" || g.prefix == "/" || !strings.HasPrefix(pattern, "/") {
+		return pattern
+	}
+	if pattern == "/" {
+		return g.prefix + "/"
+	}
+	return g.prefix + pattern
+}
+
+func (g *Group) wrap(h http.Handler) http.Handler {
+	if h == nil || len(g.mws) == 0 {
+		return h
+	}
+	wrapped := h
+	for i := len(g.mws) - 1; i >= 0; i-- {
+		wrapped = g.mws[i](wrapped)
+	}
+	return chain{Handler: wrapped, inner: h}
+}
+
+// chain — handler с middleware; в таблице маршрутов виден исходный handler.
+type chain struct {
+	http.Handler
+	inner http.Handler
+}
+
+func (c chain) HandlerName() string {
+	return handlerName(c.inner)
+}
+
+// subRouter возвращает Router под mount, в том числе обёрнутый middleware группы.
+func subRouter(h http.Handler) (*Router, bool) {
+	if c, ok := h.(chain); ok {
+		h = c.inner
+	}
+	sub, ok := h.(*Router)
+	return sub, ok
+}
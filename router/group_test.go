@@ -0,0 +1,120 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func tagMiddleware(tag string, log *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			*log = append(*log, tag+":"+Pattern(req)+":"+Param(req, "id"))
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func TestRouterWith(t *testing.T) {
+	var log []string
+	r := New()
+	r.With(tagMiddleware("a", &log), tagMiddleware("b", &log)).GET("/posts/:id", textHandler("post"))
+	r.GET("/plain", textHandler("plain"))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/7", nil))
+	if rec.Body.String() != "post" {
+		t.Fatalf("unexpected body: %q", rec.Body.String())
+	}
+	if strings.Join(log, ",") != "a:/posts/:id:7,b:/posts/:id:7" {
+		t.Fatalf("unexpected middleware log: %v", log)
+	}
+
+	log = nil
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/plain", nil))
+	if len(log) != 0 {
+		t.Fatalf("With must not affect other routes: %v", log)
+	}
+
+	log = nil
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if len(log) != 0 {
+		t.Fatalf("middleware must run only after match: %v", log)
+	}
+}
+
+func TestRouterGroup(t *testing.T) {
+	var log []string
+	r := New()
+	r.Group("/api", func(api *Group) {
+		api.Use(tagMiddleware("api", &log))
+		api.GET("/", textHandler("index"))
+		api.Group("/v1", func(v1 *Group) {
+			v1.With(tagMiddleware("v1", &log)).HandleNamed("user", http.MethodGet, "/users/:id", textHandler("user"))
+		})
+		api.Mount("/files", http.StripPrefix("", textHandler("files")))
+	})
+
+	cases := []struct {
+		path string
+		body string
+		log  string
+	}{
+		{path: "/api/", body: "index", log: "api:/api/:"},
+		{path: "/api/v1/users/3", body: "user", log: "api:/api/v1/users/:id:3,v1:/api/v1/users/:id:3"},
+		{path: "/api/files/a.txt", body: "files", log: "api:/api/files/*:"},
+	}
+	for _, tc := range cases {
+		log = nil
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Body.String() != tc.body {
+			t.Fatalf("%s: unexpected body %q", tc.path, rec.Body.String())
+		}
+		if strings.Join(log, ",") != tc.log {
+			t.Fatalf("%s: unexpected middleware log %v", tc.path, log)
+		}
+	}
+
+	got, err := r.URL("user", "id", "9")
+	if err != nil || got != "/api/v1/users/9" {
+		t.Fatalf("unexpected url: %q %v", got, err)
+	}
+	for _, route := range r.Routes() {
+		if route.Pattern == "/api/v1/users/:id" && !strings.Contains(route.Handler, "textHandler") {
+			t.Fatalf("routes must show the original handler, got %q", route.Handler)
+		}
+	}
+}
+
+func TestRouterGroupMountSubRouter(t *testing.T) {
+	var log []string
+	sub := New()
+	sub.HandleNamed("item", http.MethodGet, "/items/:id", textHandler("item"))
+	r := New()
+	r.Group("/shop", func(g *Group) {
+		g.With(tagMiddleware("shop", &log)).Mount("/", sub)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/shop/items/1", nil))
+	if rec.Body.String() != "item" || len(log) != 1 {
+		t.Fatalf("unexpected result: body=%q log=%v", rec.Body.String(), log)
+	}
+	if got, err := r.URL("item", "id", "1"); err != nil || got != "/shop/items/1" {
+		t.Fatalf("unexpected url: %q %v", got, err)
+	}
+	if routes := r.Routes(); len(routes) != 1 || routes[0].Pattern != "/shop/items/:id" {
+		t.Fatalf("unexpected routes: %+v", routes)
+	}
+}
+
+func TestRouterGroupInvalidPrefixPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+	New().Group("api/", func(g *Group) {})
+}
@@ -10,52 +10,57 @@ func splitPath(path string) []string {
 	return strings.Split(path, "/")
 }
 
-func matchPath(root *node, path string) (*node, []RouteParam, bool) {
-	segments := splitPath(path)
-	if root == nil {
-		return nil, nil, false
+// match обходит дерево по path без аллокаций и копирования сегментов.
+// Возвращает либо узел маршрута, либо mount с остатком пути.
+// Params дописываются в ps; при откате лишние значения срезаются.
+func (n *node) match(path string, ps *[]RouteParam) (*node, *mount, string) {
+	if n.mount != nil && n.mount.accepts(path) {
+		if leaf, m, rest := n.matchChildren(path, ps); leaf != nil || m != nil {
+			return leaf, m, rest
+		}
+		return nil, n.mount, n.mount.rest(path)
 	}
-
-	params := make([]RouteParam, 0)
-	n, ok := matchFrom(root, segments, 0, &params)
-	if !ok {
-		return nil, nil, false
+	if path == "" && len(n.handlers) > 0 {
+		return n, nil, ""
 	}
-	return n, params, true
+	return n.matchChildren(path, ps)
 }
 
-func matchFrom(cur *node, segments []string, idx int, params *[]RouteParam) (*node, bool) {
-	if cur == nil {
-		return nil, false
-	}
-	if idx == len(segments) {
-		if cur.wildcard != nil {
-			*params = append(*params, RouteParam{Key: cur.wcName, Value: ""})
-			return cur.wildcard, true
+func (n *node) matchChildren(path string, ps *[]RouteParam) (*node, *mount, string) {
+	if child := n.staticChild(path); child != nil {
+		if leaf, m, rest := child.match(path[len(child.prefix):], ps); leaf != nil || m != nil {
+			return leaf, m, rest
 		}
-		return cur, true
 	}
 
-	seg := segments[idx]
-	if next, ok := cur.static[seg]; ok {
-		if n, ok := matchFrom(next, segments, idx+1, params); ok {
-			return n, true
+	if len(n.params) > 0 && path != "" {
+		end := strings.IndexByte(path, '/')
+		if end == -1 {
+			end = len(path)
 		}
-	}
-
-	if cur.param != nil && seg != "" {
-		*params = append(*params, RouteParam{Key: cur.param.paramName, Value: seg})
-		if n, ok := matchFrom(cur.param, segments, idx+1, params); ok {
-			return n, true
+		seg := path[:end]
+		if seg != "" {
+			for _, child := range n.params {
+				if !child.constraint.allows(seg) {
+					continue
+				}
+				*ps = append(*ps, RouteParam{Key: child.paramName, Value: seg})
+				if leaf, m, rest := child.match(path[end:], ps); leaf != nil || m != nil {
+					return leaf, m, rest
+				}
+				*ps = (*ps)[:len(*ps)-1]
+			}
 		}
-		*params = (*params)[:len(*params)-1]
 	}
 
-	if cur.wildcard != nil {
-		tail := strings.Join(segments[idx:], "/")
-		*params = append(*params, RouteParam{Key: cur.wcName, Value: tail})
-		return cur.wildcard, true
+	if n.wildcard != nil && (path == "" || path[0] == '/') {
+		value := path
+		if value != "" {
+			value = value[1:]
+		}
+		*ps = append(*ps, RouteParam{Key: n.wildcard.paramName, Value: value})
+		return n.wildcard, nil, ""
 	}
 
-	return nil, false
+	return nil, nil, ""
 }
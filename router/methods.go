@@ -0,0 +1,102 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// isValidMethod проверяет, что метод — token по RFC 7230.
+func isValidMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for i := 0; i < len(method); i++ {
+		if !isTokenChar(method[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	switch c {
+	case '!', '#', '$', '%', '&', '\'', '*', '+', '-', '.', '^', '_', '`', '|', '~':
+		return true
+	default:
+		return false
+	}
+}
+
+// lookupHandler возвращает handler для метода с учётом неявного HEAD → GET.
+func lookupHandler(handlers map[string]http.Handler, method string) (http.Handler, bool) {
+	if h, ok := handlers[method]; ok {
+		return h, true
+	}
+	if method == http.MethodHead {
+		if h, ok := handlers[http.MethodGet]; ok {
+			return headHandler(h), true
+		}
+	}
+	return nil, false
+}
+
+func allowHeader(handlers map[string]http.Handler) string {
+	if len(handlers) == 0 {
+		return ""
+	}
+	methods := make([]string, 0, len(handlers)+2)
+	for method := range handlers {
+		methods = append(methods, method)
+	}
+	if _, ok := handlers[http.MethodGet]; ok {
+		if _, ok := handlers[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	if _, ok := handlers[http.MethodOptions]; !ok {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+func writeOptions(w http.ResponseWriter, handlers map[string]http.Handler) {
+	w.Header().Set("Allow", allowHeader(handlers))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func headHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(&headWriter{ResponseWriter: w}, r)
+	})
+}
+
+// headWriter отбрасывает тело ответа, сохраняя статус и заголовки.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *headWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *headWriter) SetErr(err error) {
+	if setter, ok := w.ResponseWriter.(interface{ SetErr(error) }); ok {
+		setter.SetErr(err)
+	}
+}
@@ -1,7 +1,9 @@
 package router
 
 import (
+	"context"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
@@ -10,25 +12,65 @@ type mount struct {
 	handler http.Handler
 }
 
-func matchMount(mounts []mount, path string) (mount, string, bool) {
-	var best mount
-	bestLen := -1
-	var rest string
-	for _, m := range mounts {
-		ok, next := matchPrefix(path, m.prefix)
-		if !ok {
-			continue
-		}
-		if len(m.prefix) > bestLen {
-			bestLen = len(m.prefix)
-			best = m
-			rest = next
-		}
+type mountCtxKey struct{}
+
+// mountState — то, что sub‑handler под Mount знает о родительских Router.
+type mountState struct {
+	prefix string       // накопленный prefix в виде pattern (/tenants/:tid)
+	params []RouteParam // params родителей; хранилище не переиспользуется
+	origin *url.URL     // URL запроса до отрезания prefix
+}
+
+func mountFromContext(ctx context.Context) *mountState {
+	s, _ := ctx.Value(mountCtxKey{}).(*mountState)
+	return s
+}
+
+// withMount добавляет prefix и params совпавшего mount к состоянию родителя.
+// params уже содержат унаследованные значения, поэтому копируются целиком.
+func withMount(ctx context.Context, m *mount, params []RouteParam, u *url.URL) context.Context {
+	s := &mountState{prefix: m.prefix, origin: u}
+	if parent := mountFromContext(ctx); parent != nil {
+		s.prefix = joinPattern(parent.prefix, m.prefix)
+		s.origin = parent.origin
+	}
+	if len(params) > 0 {
+		s.params = make([]RouteParam, len(params))
+		copy(s.params, params)
+	}
+	return context.WithValue(ctx, mountCtxKey{}, s)
+}
+
+func mountPrefix(ctx context.Context) string {
+	if s := mountFromContext(ctx); s != nil {
+		return s.prefix
+	}
+	return ""
+}
+
+// OriginalPath возвращает URL.Path запроса до отрезания prefix в Mount.
+// Вне Mount совпадает с r.URL.Path.
+func OriginalPath(r *http.Request) string {
+	if s := mountFromContext(r.Context()); s != nil {
+		return s.origin.Path
+	}
+	return r.URL.Path
+}
+
+// accepts проверяет границу сегмента после prefix: остаток пуст или начинается с /.
+func (m *mount) accepts(rest string) bool {
+	return m.prefix == "/" || rest == "" || rest[0] == '/'
+}
+
+// rest возвращает путь для sub‑handler.
+func (m *mount) rest(rest string) string {
+	if m.prefix == "/" {
+		return "/" + rest
 	}
-	if bestLen == -1 {
-		return mount{}, "", false
+	if rest == "" {
+		return "/"
 	}
-	return best, rest, true
+	return rest
 }
 
 func matchPrefix(path, prefix string) (bool, string) {
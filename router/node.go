@@ -1,19 +1,225 @@
 package router
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
 
+// node — узел сжатого radix-дерева маршрутов.
+//
+// Static-узлы хранят литерал пути в prefix (может охватывать несколько сегментов).
+// Param-узлы совпадают с одним непустым сегментом, wildcard-узлы — с остатком пути.
 type node struct {
-	static    map[string]*node
-	param     *node
-	paramName string
-	wildcard  *node
-	wcName    string
-	handlers  map[string]http.Handler
+	prefix     string
+	indices    string  // первые байты prefix у children
+	children   []*node // static-потомки
+	params     []*node // constrained раньше unconstrained
+	paramName  string
+	constraint *constraint
+	wildcard   *node
+	mount      *mount
+	pattern    string
+	origin     string // pattern, при регистрации которого создан узел
+	fold       bool   // static-литералы хранятся в нижнем регистре и сравниваются без учёта регистра
+	handlers   map[string]http.Handler
 }
 
 func newNode() *node {
 	return &node{
-		static:   map[string]*node{},
 		handlers: map[string]http.Handler{},
 	}
 }
+
+// newChild создаёт потомка с теми же настройками сравнения.
+func (n *node) newChild() *node {
+	child := newNode()
+	child.fold = n.fold
+	return child
+}
+
+// insert добавляет pattern в дерево и возвращает конечный узел.
+func (n *node) insert(pattern string) *node {
+	segments := splitPath(pattern)
+	if len(segments) == 0 {
+		return n.staticPath("/", pattern)
+	}
+
+	cur := n
+	literal := ""
+	for i, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			if len(seg) == 1 {
+				panic("router: empty wildcard name")
+			}
+			if i != len(segments)-1 {
+				panic("router: wildcard must be the last segment")
+			}
+			cur = cur.staticPath(literal, pattern)
+			return cur.wildcardChild(seg[1:], pattern)
+		case strings.HasPrefix(seg, ":"):
+			name, c := parseParam(seg)
+			cur = cur.staticPath(literal+"/", pattern)
+			literal = ""
+			cur = cur.paramChild(name, c, pattern)
+		default:
+			if n.fold {
+				seg = lowerASCII(seg)
+			}
+			literal += "/" + seg
+		}
+	}
+	return cur.staticPath(literal, pattern)
+}
+
+// staticPath возвращает узел, которым заканчивается литерал lit, расщепляя рёбра при необходимости.
+func (n *node) staticPath(lit, origin string) *node {
+	cur := n
+	for lit != "" {
+		idx := strings.IndexByte(cur.indices, lit[0])
+		if idx == -1 {
+			child := cur.newChild()
+			child.prefix = lit
+			child.origin = origin
+			cur.indices += lit[:1]
+			cur.children = append(cur.children, child)
+			return child
+		}
+
+		child := cur.children[idx]
+		common := commonPrefix(child.prefix, lit)
+		if common < len(child.prefix) {
+			mid := cur.newChild()
+			mid.prefix = child.prefix[:common]
+			mid.origin = origin
+			child.prefix = child.prefix[common:]
+			mid.indices = child.prefix[:1]
+			mid.children = []*node{child}
+			cur.children[idx] = mid
+			child = mid
+		}
+		cur = child
+		lit = lit[common:]
+	}
+	return cur
+}
+
+func (n *node) paramChild(name string, c *constraint, pattern string) *node {
+	for _, child := range n.params {
+		if child.constraint.rawValue() != c.rawValue() {
+			continue
+		}
+		if child.paramName != name {
+			panic(fmt.Sprintf("router: param conflict: %s conflicts with %s", pattern, child.origin))
+		}
+		return child
+	}
+	child := n.newChild()
+	child.paramName = name
+	child.constraint = c
+	child.origin = pattern
+	if c == nil {
+		n.params = append(n.params, child)
+		return child
+	}
+	idx := len(n.params)
+	if idx > 0 && n.params[idx-1].constraint == nil {
+		idx--
+	}
+	n.params = append(n.params, nil)
+	copy(n.params[idx+1:], n.params[idx:])
+	n.params[idx] = child
+	return child
+}
+
+func (n *node) wildcardChild(name, pattern string) *node {
+	if n.wildcard != nil {
+		if n.wildcard.paramName != name {
+			panic(fmt.Sprintf("router: wildcard conflict: %s conflicts with %s", pattern, n.wildcard.origin))
+		}
+		return n.wildcard
+	}
+	n.wildcard = n.newChild()
+	n.wildcard.paramName = name
+	n.wildcard.origin = pattern
+	return n.wildcard
+}
+
+// staticChild возвращает static-потомка, prefix которого является началом path.
+func (n *node) staticChild(path string) *node {
+	if path == "" {
+		return nil
+	}
+	first := path[0]
+	if n.fold {
+		first = toLowerASCII(first)
+	}
+	idx := strings.IndexByte(n.indices, first)
+	if idx == -1 {
+		return nil
+	}
+	child := n.children[idx]
+	if n.fold {
+		if !hasPrefixFold(path, child.prefix) {
+			return nil
+		}
+		return child
+	}
+	if !strings.HasPrefix(path, child.prefix) {
+		return nil
+	}
+	return child
+}
+
+// hasPrefixFold сравнивает path с prefix в нижнем регистре без аллокаций.
+func hasPrefixFold(path, prefix string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := 0; i < len(prefix); i++ {
+		if toLowerASCII(path[i]) != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func toLowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
+
+func lowerASCII(s string) string {
+	b := []byte(s)
+	for i := range b {
+		b[i] = toLowerASCII(b[i])
+	}
+	return string(b)
+}
+
+func commonPrefix(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+func walkNodes(n *node, fn func(*node)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	for _, child := range n.children {
+		walkNodes(child, fn)
+	}
+	for _, child := range n.params {
+		walkNodes(child, fn)
+	}
+	walkNodes(n.wildcard, fn)
+}
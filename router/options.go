@@ -0,0 +1,143 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+type options struct {
+	redirectTrailingSlash bool
+	cleanPath             bool
+	caseInsensitive       bool
+}
+
+// Option задаёт поведение Router.
+type Option func(*options)
+
+// WithRedirectTrailingSlash включает редирект /posts/ ↔ /posts, если маршрут есть
+// только в другом варианте. GET и HEAD получают 301, остальные методы — 308.
+func WithRedirectTrailingSlash() Option {
+	return func(opts *options) {
+		opts.redirectTrailingSlash = true
+	}
+}
+
+// WithCleanPath включает редирект на путь, очищенный по правилам path.Clean
+// (// и сегменты . и .. схлопываются, завершающий / сохраняется).
+func WithCleanPath() Option {
+	return func(opts *options) {
+		opts.cleanPath = true
+	}
+}
+
+// WithCaseInsensitive включает сравнение static-сегментов без учёта регистра (ASCII).
+// Значения params и pattern сохраняют исходный регистр.
+func WithCaseInsensitive() Option {
+	return func(opts *options) {
+		opts.caseInsensitive = true
+	}
+}
+
+// routingPath возвращает путь для матчинга. Если в пути есть закодированный /,
+// используется URL.RawPath, чтобы %2F не разбивал значение параметра на сегменты.
+func routingPath(u *url.URL) (string, bool) {
+	if u.RawPath != "" && hasEncodedSlash(u.RawPath) {
+		return u.RawPath, true
+	}
+	return u.Path, false
+}
+
+func hasEncodedSlash(s string) bool {
+	for i := 0; i+2 < len(s); i++ {
+		if s[i] == '%' && s[i+1] == '2' && (s[i+2] == 'F' || s[i+2] == 'f') {
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeParams раскодирует значения params, найденных по RawPath.
+func unescapeParams(params []RouteParam) {
+	for i := range params {
+		if strings.IndexByte(params[i].Value, '%') == -1 {
+			continue
+		}
+		if v, err := url.PathUnescape(params[i].Value); err == nil {
+			params[i].Value = v
+		}
+	}
+}
+
+// cleanPath возвращает путь по правилам path.Clean с сохранением завершающего /.
+// Для уже чистого пути не аллоцирует.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	c := path.Clean(p)
+	if c != "/" && p[len(p)-1] == '/' {
+		if c == p[:len(p)-1] {
+			return p
+		}
+		return c + "/"
+	}
+	return c
+}
+
+func toggleSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return strings.TrimSuffix(p, "/")
+	}
+	return p + "/"
+}
+
+// hasRoute сообщает, есть ли в дереве маршрут на path.
+func (t *table) hasRoute(p string) bool {
+	if p == "" {
+		return false
+	}
+	rc := acquireScratch()
+	n, _, _ := t.root.match(p, &rc.params)
+	releaseScratch(rc)
+	return n != nil
+}
+
+// fixTrailingSlash возвращает вариант p с другим завершающим /, если маршрут есть только в нём.
+func (t *table) fixTrailingSlash(p string) (string, bool) {
+	if !t.opts.redirectTrailingSlash || p == "/" || t.hasRoute(p) {
+		return "", false
+	}
+	if alt := toggleSlash(p); t.hasRoute(alt) {
+		return alt, true
+	}
+	return "", false
+}
+
+// redirect отправляет клиента на target. Под Mount к target добавляется отрезанный prefix.
+// GET и HEAD получают 301, остальные методы — 308, чтобы сохранить метод и тело.
+func redirect(w http.ResponseWriter, req *http.Request, target string, raw bool) {
+	if !raw {
+		target = (&url.URL{Path: target}).EscapedPath()
+	}
+	if ms := mountFromContext(req.Context()); ms != nil {
+		orig := ms.origin.EscapedPath()
+		cur := req.URL.EscapedPath()
+		if strings.HasSuffix(orig, cur) {
+			target = orig[:len(orig)-len(cur)] + target
+		}
+	}
+	if strings.HasPrefix(target, "//") {
+		target = "/" + strings.TrimLeft(target, "/")
+	}
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	code := http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	w.Header().Set("Location", target)
+	w.WriteHeader(code)
+}
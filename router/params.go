@@ -3,6 +3,11 @@ package router
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	apperrors "github.com/sejta/nope/errors"
 )
 
 // RouteParam описывает параметр маршрута.
@@ -11,34 +16,152 @@ type RouteParam struct {
 	Value string
 }
 
-type paramsKey struct{}
+type routeCtxKey struct{}
+
+// routeContext — неизменяемый контекст совпавшего маршрута с params.
+// Создаётся на каждый запрос с params: handler может сохранить r.Context()
+// (например, в горутине), поэтому переиспользовать его нельзя.
+type routeContext struct {
+	context.Context
+	params []RouteParam
+	buf    [4]RouteParam
+}
+
+// routeRequest — копия запроса и её контекст маршрута в одной аллокации.
+type routeRequest struct {
+	req http.Request
+	ctx routeContext
+}
+
+// withRouteParams возвращает копию req с контекстом маршрута, несущим копию params.
+func withRouteParams(req *http.Request, params []RouteParam) *http.Request {
+	rr := &routeRequest{}
+	c := &rr.ctx
+	c.Context = req.Context()
+	if len(params) <= len(c.buf) {
+		c.params = c.buf[:len(params):len(params)]
+	} else {
+		c.params = make([]RouteParam, len(params))
+	}
+	copy(c.params, params)
+	// WithContext встраивается, и его копия не покидает стек.
+	rr.req = *req.WithContext(c)
+	return &rr.req
+}
+
+func (c *routeContext) Value(key any) any {
+	if key == (routeCtxKey{}) {
+		return c
+	}
+	return c.Context.Value(key)
+}
+
+// paramScratch — буфер params на время матчинга; наружу не передаётся.
+type paramScratch struct {
+	params []RouteParam
+}
+
+var paramScratchPool = sync.Pool{
+	New: func() any {
+		return &paramScratch{params: make([]RouteParam, 0, 8)}
+	},
+}
+
+func acquireScratch() *paramScratch {
+	return paramScratchPool.Get().(*paramScratch)
+}
+
+func releaseScratch(s *paramScratch) {
+	clear(s.params)
+	s.params = s.params[:0]
+	paramScratchPool.Put(s)
+}
+
+// routeParams возвращает params совпавшего маршрута вместе с унаследованными от Mount.
+// Если sub‑handler под Mount не Router, остаются только params родителей.
+func routeParams(r *http.Request) []RouteParam {
+	ctx := r.Context()
+	if c, ok := ctx.Value(routeCtxKey{}).(*routeContext); ok && c != nil {
+		return c.params
+	}
+	if s := mountFromContext(ctx); s != nil {
+		return s.params
+	}
+	return nil
+}
 
 // Param возвращает значение параметра по ключу.
+// При совпадении имён у родителя под Mount и sub‑router побеждает sub‑router.
 func Param(r *http.Request, key string) string {
-	for _, p := range Params(r) {
-		if p.Key == key {
-			return p.Value
+	params := routeParams(r)
+	for i := len(params) - 1; i >= 0; i-- {
+		if params[i].Key == key {
+			return params[i].Value
 		}
 	}
 	return ""
 }
 
-// Params возвращает все параметры маршрута.
+// Params возвращает копию всех параметров маршрута: сначала унаследованные от Mount, затем свои.
 func Params(r *http.Request) []RouteParam {
-	val := r.Context().Value(paramsKey{})
-	if val == nil {
+	params := routeParams(r)
+	if len(params) == 0 {
 		return nil
 	}
-	params, ok := val.([]RouteParam)
-	if !ok {
-		return nil
+	out := make([]RouteParam, len(params))
+	copy(out, params)
+	return out
+}
+
+// ParamInt возвращает параметр как int.
+// При отсутствии или ошибке разбора возвращает AppError 400 с fields[key].
+func ParamInt(r *http.Request, key string) (int, error) {
+	raw, err := requiredParam(r, key)
+	if err != nil {
+		return 0, err
+	}
+	v, convErr := strconv.Atoi(raw)
+	if convErr != nil {
+		return 0, invalidParam(key, "must be an integer")
 	}
-	return params
+	return v, nil
 }
 
-func withParams(ctx context.Context, params []RouteParam) context.Context {
-	if len(params) == 0 {
-		return ctx
+// ParamInt64 возвращает параметр как int64.
+// При отсутствии или ошибке разбора возвращает AppError 400 с fields[key].
+func ParamInt64(r *http.Request, key string) (int64, error) {
+	raw, err := requiredParam(r, key)
+	if err != nil {
+		return 0, err
+	}
+	v, convErr := strconv.ParseInt(raw, 10, 64)
+	if convErr != nil {
+		return 0, invalidParam(key, "must be an integer")
 	}
-	return context.WithValue(ctx, paramsKey{}, params)
+	return v, nil
+}
+
+// ParamUUID возвращает параметр как UUID в каноническом виде (нижний регистр).
+// При отсутствии или ошибке разбора возвращает AppError 400 с fields[key].
+func ParamUUID(r *http.Request, key string) (string, error) {
+	raw, err := requiredParam(r, key)
+	if err != nil {
+		return "", err
+	}
+	if !isUUID(raw) {
+		return "", invalidParam(key, "must be a uuid")
+	}
+	return strings.ToLower(raw), nil
+}
+
+func requiredParam(r *http.Request, key string) (string, error) {
+	raw := Param(r, key)
+	if raw == "" {
+		return "", invalidParam(key, "required")
+	}
+	return raw, nil
+}
+
+func invalidParam(key, reason string) error {
+	return apperrors.WithField(apperrors.E(http.StatusBadRequest, CodeInvalidParam, MsgInvalidParam), key, reason)
 }
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/sejta/nope/internal/routeinfo"
+)
+
+// Pattern возвращает шаблон совпавшего маршрута (например, /posts/:id).
+// Для маршрутов под Mount шаблон включает prefix.
+//
+// Router сохраняет шаблон в r.Pattern так же, как это делает http.ServeMux.
+func Pattern(r *http.Request) string {
+	return r.Pattern
+}
+
+// setPattern сохраняет шаблон в запросе и сообщает его app hooks.
+func setPattern(req *http.Request, pattern string) {
+	req.Pattern = pattern
+	routeinfo.FromContext(req.Context()).SetPattern(pattern)
+}
+
+// markUnmatched сообщает app hooks, что запрос обработан без совпадения маршрута
+// (404, 405, автоматический OPTIONS, redirect).
+func markUnmatched(req *http.Request) {
+	routeinfo.FromContext(req.Context()).SetUnmatched()
+}
+
+func joinPattern(prefix, pattern string) string {
+	if prefix == "" || prefix == "/" {
+		return pattern
+	}
+	return prefix + pattern
+}
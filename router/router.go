@@ -1,77 +1,74 @@
 package router
 
 import (
+	"fmt"
 	"net/http"
-	"sort"
+	"net/url"
 	"strings"
+	"sync/atomic"
+
+	apperrors "github.com/sejta/nope/errors"
 )
 
 // Router — минимальный HTTP-роутер nope.
+//
+// Маршруты регистрируются до первого ServeHTTP; после этого Router только читает
+// таблицу, а замена выполняется атомарно через Swap.
 type Router struct {
-	root   *node
-	mounts []mount
+	cur     atomic.Pointer[table]
+	serving atomic.Bool
+}
+
+// table — таблица маршрутов. Опубликованная таблица не изменяется.
+type table struct {
+	opts             options
+	root             *node
+	mounts           []*mount
+	names            map[string]routeName
+	notFound         http.Handler
+	methodNotAllowed http.Handler
 }
 
 // New создаёт новый Router.
-func New() *Router {
-	return &Router{
+// Без опций путь матчится как есть: без редиректов и с учётом регистра.
+func New(opts ...Option) *Router {
+	t := &table{
 		root: newNode(),
 	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&t.opts)
+		}
+	}
+	t.root.fold = t.opts.caseInsensitive
+	r := &Router{}
+	r.cur.Store(t)
+	return r
 }
 
 // Handle регистрирует handler на метод и паттерн.
+// Метод — любой token по RFC 7230 (например, PURGE или REPORT).
 func (r *Router) Handle(method, pattern string, h http.Handler) {
 	if h == nil {
 		panic("router: handler is nil")
 	}
 	method = strings.ToUpper(method)
-	if !isSupportedMethod(method) {
-		panic("router: unsupported method")
+	if !isValidMethod(method) {
+		panic("router: invalid method")
 	}
 	if pattern == "" || !strings.HasPrefix(pattern, "/") {
 		panic("router: pattern must start with /")
 	}
-
-	cur := r.root
-	segments := splitPath(pattern)
-	for i, seg := range segments {
-		switch {
-		case strings.HasPrefix(seg, "*"):
-			if len(seg) == 1 {
-				panic("router: empty wildcard name")
-			}
-			if i != len(segments)-1 {
-				panic("router: wildcard must be the last segment")
-			}
-			if cur.wildcard != nil && cur.wcName != seg[1:] {
-				panic("router: wildcard conflict")
-			}
-			if cur.wildcard == nil {
-				cur.wildcard = newNode()
-				cur.wcName = seg[1:]
-			}
-			cur = cur.wildcard
-			goto done
-		case strings.HasPrefix(seg, ":"):
-			if len(seg) == 1 {
-				panic("router: empty param name")
-			}
-			if cur.param == nil {
-				cur.param = newNode()
-				cur.param.paramName = seg[1:]
-			}
-			cur = cur.param
-		default:
-			next := cur.static[seg]
-			if next == nil {
-				next = newNode()
-				cur.static[seg] = next
-			}
-			cur = next
-		}
+	t := r.mutable()
+	if m, ok := t.shadowingMount(pattern); ok {
+		panic(fmt.Sprintf("router: route %s is shadowed by mount %s", pattern, m.prefix))
 	}
-done:
 
+	cur := t.root.insert(pattern)
+	if _, ok := cur.handlers[method]; ok {
+		panic(fmt.Sprintf("router: duplicate route %s %s", method, pattern))
+	}
+	cur.pattern = pattern
 	cur.handlers[method] = h
 }
 
@@ -105,7 +102,20 @@ func (r *Router) DELETE(pattern string, h http.Handler) {
 	r.Handle(http.MethodDelete, pattern, h)
 }
 
+// HEAD регистрирует handler на HEAD.
+// Без явной регистрации HEAD обслуживается GET-хендлером без тела.
+func (r *Router) HEAD(pattern string, h http.Handler) {
+	r.Handle(http.MethodHead, pattern, h)
+}
+
+// OPTIONS регистрирует handler на OPTIONS.
+// Без явной регистрации OPTIONS отвечает 204 с заголовком Allow.
+func (r *Router) OPTIONS(pattern string, h http.Handler) {
+	r.Handle(http.MethodOptions, pattern, h)
+}
+
 // Mount монтирует под‑хендлер на prefix.
+// Prefix может содержать :param; его значения доступны sub‑handler через Param.
 func (r *Router) Mount(prefix string, h http.Handler) {
 	if h == nil {
 		panic("router: handler is nil")
@@ -116,62 +126,177 @@ func (r *Router) Mount(prefix string, h http.Handler) {
 	if prefix != "/" && strings.HasSuffix(prefix, "/") {
 		panic("router: prefix must not end with /")
 	}
-	r.mounts = append(r.mounts, mount{prefix: prefix, handler: h})
+	if strings.Contains(prefix, "/*") {
+		panic("router: prefix must not contain wildcard")
+	}
+	t := r.mutable()
+	for _, m := range t.mounts {
+		if m.prefix == prefix {
+			panic(fmt.Sprintf("router: duplicate mount %s", prefix))
+		}
+	}
+	for _, pattern := range t.patterns() {
+		if ok, _ := matchPrefix(pattern, prefix); ok {
+			panic(fmt.Sprintf("router: mount %s shadows route %s", prefix, pattern))
+		}
+	}
+	m := &mount{prefix: prefix, handler: h}
+	t.root.insert(prefix).mount = m
+	t.mounts = append(t.mounts, m)
+}
+
+// NotFound задаёт handler для 404. nil возвращает дефолтный JSON-ответ not_found.
+func (r *Router) NotFound(h http.Handler) {
+	r.mutable().notFound = h
+}
+
+// MethodNotAllowed задаёт handler для 405. nil возвращает дефолтный JSON-ответ method_not_allowed.
+// Заголовок Allow выставляется до вызова handler.
+func (r *Router) MethodNotAllowed(h http.Handler) {
+	r.mutable().methodNotAllowed = h
 }
 
 // ServeHTTP реализует net/http.
+//
+// Для static-маршрутов матчинг не аллоцирует; для маршрутов с params —
+// одна аллокация: копия запроса вместе с контекстом маршрута.
+// Под Mount params родительских Router доступны наравне со своими.
+// Запрос целиком обслуживается таблицей, актуальной на момент его начала.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	if m, rest, ok := matchMount(r.mounts, req.URL.Path); ok {
-		r.dispatchMount(w, req, m, rest)
+	if !r.serving.Load() {
+		r.serving.Store(true)
+	}
+	r.cur.Load().serve(w, req)
+}
+
+func (t *table) serve(w http.ResponseWriter, req *http.Request) {
+	rc := acquireScratch()
+	ms := mountFromContext(req.Context())
+	if ms != nil {
+		rc.params = append(rc.params, ms.params...)
+	}
+	inherited := len(rc.params)
+
+	path, raw := routingPath(req.URL)
+	if t.opts.cleanPath {
+		if clean := cleanPath(path); clean != path {
+			releaseScratch(rc)
+			markUnmatched(req)
+			if alt, ok := t.fixTrailingSlash(clean); ok {
+				clean = alt
+			}
+			redirect(w, req, clean, raw)
+			return
+		}
+	}
+
+	n, m, rest := t.root.match(path, &rc.params)
+	if raw {
+		if n == nil && m == nil {
+			// Static-сегменты могли быть закодированы без необходимости: пробуем URL.Path.
+			rc.params = rc.params[:inherited]
+			path, raw = req.URL.Path, false
+			n, m, rest = t.root.match(path, &rc.params)
+		} else {
+			unescapeParams(rc.params[inherited:])
+		}
+	}
+	if m != nil {
+		ctx := withMount(req.Context(), m, rc.params, req.URL)
+		releaseScratch(rc)
+		dispatchMount(w, req.WithContext(ctx), m, rest, raw)
+		return
+	}
+	if n == nil {
+		releaseScratch(rc)
+		markUnmatched(req)
+		if alt, ok := t.fixTrailingSlash(path); ok {
+			redirect(w, req, alt, raw)
+			return
+		}
+		t.serveNotFound(w, req)
 		return
 	}
 
-	n, params, ok := matchPath(r.root, req.URL.Path)
-	if !ok || n == nil || len(n.handlers) == 0 {
-		if !isSupportedMethod(req.Method) {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+	h, ok := lookupHandler(n.handlers, req.Method)
+	if !ok {
+		releaseScratch(rc)
+		markUnmatched(req)
+		if req.Method == http.MethodOptions {
+			writeOptions(w, n.handlers)
 			return
 		}
-		w.WriteHeader(http.StatusNotFound)
+		w.Header().Set("Allow", allowHeader(n.handlers))
+		t.serveMethodNotAllowed(w, req)
 		return
 	}
 
-	if h, ok := n.handlers[req.Method]; ok {
-		req = req.WithContext(withParams(req.Context(), params))
+	pattern := n.pattern
+	if ms != nil {
+		pattern = joinPattern(ms.prefix, pattern)
+	}
+	if len(rc.params) == 0 {
+		releaseScratch(rc)
+		setPattern(req, pattern)
 		h.ServeHTTP(w, req)
 		return
 	}
 
-	allow := allowHeader(n.handlers)
-	if allow != "" {
-		w.Header().Set("Allow", allow)
-	}
-	w.WriteHeader(http.StatusMethodNotAllowed)
+	req = withRouteParams(req, rc.params)
+	releaseScratch(rc)
+	setPattern(req, pattern)
+	h.ServeHTTP(w, req)
 }
 
-func (r *Router) dispatchMount(w http.ResponseWriter, req *http.Request, m mount, rest string) {
-	req2 := req.Clone(req.Context())
-	req2.URL.Path = rest
-	m.handler.ServeHTTP(w, req2)
+// dispatchMount передаёт запрос с отрезанным prefix; req уже несёт состояние mount.
+// Если матчинг шёл по RawPath, rest закодирован и раскодируется в URL.Path.
+func dispatchMount(w http.ResponseWriter, req *http.Request, m *mount, rest string, raw bool) {
+	u := *req.URL
+	u.Path = rest
+	u.RawPath = ""
+	if raw {
+		if p, err := url.PathUnescape(rest); err == nil {
+			u.Path = p
+			u.RawPath = rest
+		}
+	}
+	req.URL = &u
+	setPattern(req, joinPattern(mountPrefix(req.Context()), "/*"))
+	m.handler.ServeHTTP(w, req)
 }
 
-func allowHeader(handlers map[string]http.Handler) string {
-	if len(handlers) == 0 {
-		return ""
+// shadowingMount возвращает mount, prefix которого перекрывает pattern.
+func (t *table) shadowingMount(pattern string) (*mount, bool) {
+	for _, m := range t.mounts {
+		if ok, _ := matchPrefix(pattern, m.prefix); ok {
+			return m, true
+		}
 	}
-	methods := make([]string, 0, len(handlers))
-	for method := range handlers {
-		methods = append(methods, method)
+	return nil, false
+}
+
+func (t *table) patterns() []string {
+	out := make([]string, 0)
+	walkNodes(t.root, func(n *node) {
+		if len(n.handlers) > 0 {
+			out = append(out, n.pattern)
+		}
+	})
+	return out
+}
+
+func (t *table) serveNotFound(w http.ResponseWriter, req *http.Request) {
+	if t.notFound != nil {
+		t.notFound.ServeHTTP(w, req)
+		return
 	}
-	sort.Strings(methods)
-	return strings.Join(methods, ", ")
+	apperrors.WriteError(w, req, apperrors.E(http.StatusNotFound, apperrors.CodeNotFound, apperrors.MsgNotFound))
 }
 
-func isSupportedMethod(method string) bool {
-	switch method {
-	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
-		return true
-	default:
-		return false
+func (t *table) serveMethodNotAllowed(w http.ResponseWriter, req *http.Request) {
+	if t.methodNotAllowed != nil {
+		t.methodNotAllowed.ServeHTTP(w, req)
+		return
 	}
+	apperrors.WriteError(w, req, apperrors.E(http.StatusMethodNotAllowed, apperrors.CodeMethodNotAllowed, apperrors.MsgMethodNotAllowed))
 }
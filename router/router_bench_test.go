@@ -0,0 +1,123 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *discardWriter) WriteHeader(status int) {}
+
+func benchRouter(opts ...Option) *Router {
+	r := New(opts...)
+	noop := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	readParam := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = Param(req, "id")
+	})
+	r.GET("/", noop)
+	r.GET("/healthz", noop)
+	r.GET("/api/v1/users", noop)
+	r.GET("/api/v1/users/list", noop)
+	r.GET("/api/v1/users/:id", readParam)
+	r.GET("/api/v1/users/:id/posts/:postID", readParam)
+	r.GET("/api/v1/orders/:id<int>", readParam)
+	r.GET("/assets/*path", noop)
+	for _, p := range []string{"/a", "/b", "/c", "/d", "/e", "/f", "/g", "/h"} {
+		r.GET("/api/v1"+p+"/items", noop)
+		r.GET("/api/v1"+p+"/items/:id", readParam)
+	}
+	return r
+}
+
+func TestRouterMatchAllocations(t *testing.T) {
+	plain := benchRouter()
+	withOpts := benchRouter(WithCleanPath(), WithRedirectTrailingSlash(), WithCaseInsensitive())
+	cases := []struct {
+		path      string
+		maxAllocs float64
+	}{
+		{path: "/healthz", maxAllocs: 0},
+		{path: "/api/v1/users/list", maxAllocs: 0},
+		{path: "/api/v1/users/42", maxAllocs: 1},
+		{path: "/api/v1/users/42/posts/7", maxAllocs: 1},
+		{path: "/api/v1/orders/42", maxAllocs: 1},
+		{path: "/assets/css/app.css", maxAllocs: 1},
+	}
+	for _, r := range []*Router{plain, withOpts} {
+		for _, tc := range cases {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := &discardWriter{header: http.Header{}}
+			allocs := testing.AllocsPerRun(200, func() {
+				r.ServeHTTP(w, req)
+			})
+			if allocs > tc.maxAllocs {
+				t.Fatalf("%s: allocs=%v want<=%v", tc.path, allocs, tc.maxAllocs)
+			}
+		}
+	}
+}
+
+func benchmarkServe(b *testing.B, method, path string) {
+	r := benchRouter()
+	req := httptest.NewRequest(method, path, nil)
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkRouterStaticRoot(b *testing.B) {
+	benchmarkServe(b, http.MethodGet, "/")
+}
+
+func BenchmarkRouterStatic(b *testing.B) {
+	benchmarkServe(b, http.MethodGet, "/api/v1/users/list")
+}
+
+func BenchmarkRouterParam(b *testing.B) {
+	benchmarkServe(b, http.MethodGet, "/api/v1/users/42")
+}
+
+func BenchmarkRouterTwoParams(b *testing.B) {
+	benchmarkServe(b, http.MethodGet, "/api/v1/users/42/posts/7")
+}
+
+func BenchmarkRouterConstrainedParam(b *testing.B) {
+	benchmarkServe(b, http.MethodGet, "/api/v1/orders/42")
+}
+
+func BenchmarkRouterWildcard(b *testing.B) {
+	benchmarkServe(b, http.MethodGet, "/assets/css/app.css")
+}
+
+func BenchmarkRouterNotFound(b *testing.B) {
+	benchmarkServe(b, http.MethodGet, "/missing/path")
+}
+
+func BenchmarkRouterMount(b *testing.B) {
+	root := New()
+	sub := New()
+	sub.GET("/ping", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	root.Mount("/api", sub)
+	req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root.ServeHTTP(w, req)
+	}
+}
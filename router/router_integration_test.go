@@ -157,12 +157,30 @@ func TestRouterIntegrationBasics(t *testing.T) {
 			wantAllowContains: http.MethodGet,
 		},
 		{
-			name:              "unsupported method",
+			name:              "auto options",
 			method:            http.MethodOptions,
 			path:              "/healthz",
-			wantStatus:        http.StatusMethodNotAllowed,
+			wantStatus:        http.StatusNoContent,
 			wantAllowContains: http.MethodGet,
 		},
+		{
+			name:              "unknown method",
+			method:            "PURGE",
+			path:              "/healthz",
+			wantStatus:        http.StatusMethodNotAllowed,
+			wantAllowContains: http.MethodHead,
+		},
+		{
+			name:       "auto head",
+			method:     http.MethodHead,
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, resp response) {
+				if len(resp.body) != 0 {
+					t.Fatalf("ожидали пустое тело, получили %q", string(resp.body))
+				}
+			},
+		},
 		{
 			name:       "mount root",
 			method:     http.MethodGet,
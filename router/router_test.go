@@ -1,10 +1,15 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+
+	apperrors "github.com/sejta/nope/errors"
 )
 
 func TestRouterStaticMatch(t *testing.T) {
@@ -154,3 +159,845 @@ func TestRouterTrailingSlash(t *testing.T) {
 		t.Fatalf("unexpected status: %d", rec.Code)
 	}
 }
+
+func TestRouterHeadFallsBackToGet(t *testing.T) {
+	r := New()
+	r.GET("/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Test", "ok")
+		_, _ = w.Write([]byte("body"))
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodHead, "/x", nil)
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if rec.Header().Get("X-Test") != "ok" {
+		t.Fatalf("missing header from GET handler")
+	}
+	if rec.Body.Len() != 0 {
+		t.Fatalf("unexpected body: %q", rec.Body.String())
+	}
+}
+
+func TestRouterExplicitHeadWins(t *testing.T) {
+	r := New()
+	var got string
+	r.GET("/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = "get"
+	}))
+	r.HEAD("/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = "head"
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/x", nil))
+
+	if got != "head" {
+		t.Fatalf("unexpected handler: %q", got)
+	}
+}
+
+func TestRouterAutoOptions(t *testing.T) {
+	r := New()
+	r.GET("/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	r.POST("/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/x", nil))
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS, POST" {
+		t.Fatalf("unexpected allow header: %q", allow)
+	}
+}
+
+func TestRouterCustomMethod(t *testing.T) {
+	r := New()
+	called := false
+	r.Handle("purge", "/cache", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("PURGE", "/cache", nil))
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if !called {
+		t.Fatal("handler was not called")
+	}
+}
+
+func TestRouterInvalidMethodPanics(t *testing.T) {
+	r := New()
+	defer func() {
+		if rec := recover(); rec == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	r.Handle("BAD METHOD", "/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+}
+
+func TestRouterPattern(t *testing.T) {
+	r := New()
+	var got string
+	r.GET("/posts/:id", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = Pattern(req)
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/1", nil))
+
+	if got != "/posts/:id" {
+		t.Fatalf("unexpected pattern: %q", got)
+	}
+}
+
+func TestRouterPatternMount(t *testing.T) {
+	root := New()
+	api := New()
+	v1 := New()
+	var got, gotRaw string
+	v1.GET("/users/*path", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = Pattern(req)
+	}))
+	api.Mount("/v1", v1)
+	root.Mount("/api", api)
+	root.Mount("/static", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotRaw = Pattern(req)
+	}))
+
+	rec := httptest.NewRecorder()
+	root.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/a/b", nil))
+	if got != "/api/v1/users/*path" {
+		t.Fatalf("unexpected pattern: %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	root.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+	if gotRaw != "/static/*" {
+		t.Fatalf("unexpected mount pattern: %q", gotRaw)
+	}
+}
+
+func listUsers(w http.ResponseWriter, req *http.Request) {}
+
+func TestRouterRoutes(t *testing.T) {
+	r := New()
+	r.GET("/users", http.HandlerFunc(listUsers))
+	r.POST("/users", http.HandlerFunc(listUsers))
+	api := New()
+	api.GET("/posts/:id", http.HandlerFunc(listUsers))
+	r.Mount("/api", api)
+	r.Mount("/static", http.NotFoundHandler())
+
+	got := r.Routes()
+	want := []Route{
+		{Method: "GET", Pattern: "/api/posts/:id", Mount: "/api", Handler: "github.com/sejta/nope/router.listUsers"},
+		{Method: "*", Pattern: "/static/*", Mount: "/static", Handler: "net/http.NotFound"},
+		{Method: "GET", Pattern: "/users", Handler: "github.com/sejta/nope/router.listUsers"},
+		{Method: "POST", Pattern: "/users", Handler: "github.com/sejta/nope/router.listUsers"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected routes: %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("route %d: got %+v want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRouterDefaultErrorContract(t *testing.T) {
+	r := New()
+	r.GET("/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	cases := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{method: http.MethodGet, path: "/missing", status: http.StatusNotFound, code: `"code":"not_found"`},
+		{method: http.MethodPost, path: "/x", status: http.StatusMethodNotAllowed, code: `"code":"method_not_allowed"`},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if rec.Code != tc.status {
+			t.Fatalf("unexpected status: %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+			t.Fatalf("unexpected content type: %q", ct)
+		}
+		if !strings.Contains(rec.Body.String(), tc.code) {
+			t.Fatalf("unexpected body: %q", rec.Body.String())
+		}
+	}
+}
+
+func TestRouterCustomNotFoundAndMethodNotAllowed(t *testing.T) {
+	r := New()
+	r.GET("/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	r.NotFound(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	var allow string
+	r.MethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		allow = w.Header().Get("Allow")
+		w.WriteHeader(http.StatusConflict)
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/x", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if !strings.Contains(allow, "GET") {
+		t.Fatalf("allow header missing GET: %q", allow)
+	}
+}
+
+func TestRouterParamConstraints(t *testing.T) {
+	r := New()
+	text := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte(s + ":" + Pattern(req)))
+		})
+	}
+	r.GET("/items/:id<int>", text("int"))
+	r.GET("/items/:uuid<uuid>", text("uuid"))
+	r.GET("/items/:slug<[a-z0-9-]+>", text("slug"))
+	r.GET("/items/:any", text("any"))
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{path: "/items/42", want: "int:/items/:id<int>"},
+		{path: "/items/-7", want: "int:/items/:id<int>"},
+		{path: "/items/0b8e5c5e-3f0a-4c4e-9a5d-2f1f6f6a1b2c", want: "uuid:/items/:uuid<uuid>"},
+		{path: "/items/hello-world", want: "slug:/items/:slug<[a-z0-9-]+>"},
+		{path: "/items/Hello_World", want: "any:/items/:any"},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Body.String() != tc.want {
+			t.Fatalf("%s: got %q want %q", tc.path, rec.Body.String(), tc.want)
+		}
+	}
+}
+
+func TestRouterParamConstraintFallsThroughToNotFound(t *testing.T) {
+	r := New()
+	r.GET("/users/:id<int>", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/abc", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+}
+
+func TestRouterInvalidConstraintPanics(t *testing.T) {
+	patterns := []string{"/x/:id<>", "/x/:id<int", "/x/:<int>", "/x/:id<[a-z>"}
+	for _, p := range patterns {
+		func() {
+			defer func() {
+				if rec := recover(); rec == nil {
+					t.Fatalf("expected panic for %q", p)
+				}
+			}()
+			New().GET(p, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		}()
+	}
+}
+
+func TestParamTypedAccessors(t *testing.T) {
+	r := New()
+	var gotInt int
+	var gotUUID string
+	var intErr, uuidErr error
+	r.GET("/a/:id/:uid", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotInt, intErr = ParamInt(req, "id")
+		gotUUID, uuidErr = ParamUUID(req, "uid")
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/a/12/0B8E5C5E-3F0A-4C4E-9A5D-2F1F6F6A1B2C", nil))
+	if intErr != nil || gotInt != 12 {
+		t.Fatalf("unexpected int: %d %v", gotInt, intErr)
+	}
+	if uuidErr != nil || gotUUID != "0b8e5c5e-3f0a-4c4e-9a5d-2f1f6f6a1b2c" {
+		t.Fatalf("unexpected uuid: %q %v", gotUUID, uuidErr)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/a/x/y", nil))
+	var app *apperrors.AppError
+	if !errors.As(intErr, &app) {
+		t.Fatalf("expected AppError, got %v", intErr)
+	}
+	if app.Status != http.StatusBadRequest || app.Code != CodeInvalidParam || app.Fields["id"] == "" {
+		t.Fatalf("unexpected error: %+v", app)
+	}
+	if !errors.As(uuidErr, &app) || app.Fields["uid"] == "" {
+		t.Fatalf("unexpected uuid error: %v", uuidErr)
+	}
+}
+
+func TestParamIntMissing(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, err := ParamInt64(req, "id")
+	var app *apperrors.AppError
+	if !errors.As(err, &app) || app.Fields["id"] != "required" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRouterURL(t *testing.T) {
+	r := New()
+	noop := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	r.HandleNamed("post", http.MethodGet, "/posts/:id<int>", noop)
+	r.HandleNamed("file", http.MethodGet, "/files/*path", noop)
+	r.HandleNamed("user", http.MethodGet, "/users/:name", noop)
+	api := New()
+	api.HandleNamed("api.ping", http.MethodGet, "/ping", noop)
+	r.Mount("/api", api)
+
+	cases := []struct {
+		name   string
+		params []string
+		want   string
+	}{
+		{name: "post", params: []string{"id", "42"}, want: "/posts/42"},
+		{name: "file", params: []string{"path", "a b/c.png"}, want: "/files/a%20b/c.png"},
+		{name: "file", params: []string{"path", ""}, want: "/files"},
+		{name: "user", params: []string{"name", "a/b"}, want: "/users/a%2Fb"},
+		{name: "api.ping", want: "/api/ping"},
+	}
+	for _, tc := range cases {
+		got, err := r.URL(tc.name, tc.params...)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: got %q want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRouterURLErrors(t *testing.T) {
+	r := New()
+	r.HandleNamed("post", http.MethodGet, "/posts/:id<int>", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	if _, err := r.URL("missing"); !errors.Is(err, ErrUnknownRoute) {
+		t.Fatalf("expected ErrUnknownRoute, got %v", err)
+	}
+	if _, err := r.URL("post"); !errors.Is(err, ErrMissingParam) {
+		t.Fatalf("expected ErrMissingParam, got %v", err)
+	}
+	if _, err := r.URL("post", "id", "abc"); !errors.Is(err, ErrInvalidParam) {
+		t.Fatalf("expected ErrInvalidParam, got %v", err)
+	}
+	if _, err := r.URL("post", "id"); !errors.Is(err, ErrOddParams) {
+		t.Fatalf("expected ErrOddParams, got %v", err)
+	}
+}
+
+func TestRouterDuplicateRouteNamePanics(t *testing.T) {
+	noop := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	cases := []struct {
+		method  string
+		pattern string
+	}{
+		{http.MethodPost, "/a"},
+		{http.MethodGet, "/b"},
+	}
+	for _, tc := range cases {
+		r := New()
+		r.HandleNamed("x", http.MethodGet, "/a", noop)
+		func() {
+			defer func() {
+				if rec := recover(); rec == nil {
+					t.Fatalf("%s %s: expected panic", tc.method, tc.pattern)
+				}
+			}()
+			r.HandleNamed("x", tc.method, tc.pattern, noop)
+		}()
+	}
+}
+
+func TestRoutesNamesPerMethod(t *testing.T) {
+	r := New()
+	noop := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	r.HandleNamed("users.get", http.MethodGet, "/users/:id", noop)
+	r.HandleNamed("users.update", http.MethodPut, "/users/:id", noop)
+	r.HandleNamed("users.delete", "delete", "/users/:id", noop)
+	r.Handle(http.MethodPatch, "/users/:id", noop)
+
+	want := map[string]string{
+		http.MethodDelete: "users.delete",
+		http.MethodGet:    "users.get",
+		http.MethodPatch:  "",
+		http.MethodPut:    "users.update",
+	}
+	routes := r.Routes()
+	if len(routes) != len(want) {
+		t.Fatalf("routes: %+v", routes)
+	}
+	for _, route := range routes {
+		if route.Name != want[route.Method] {
+			t.Fatalf("%s %s: name=%q want %q", route.Method, route.Pattern, route.Name, want[route.Method])
+		}
+	}
+	if u, err := r.URL("users.update", "id", "7"); err != nil || u != "/users/7" {
+		t.Fatalf("url=%q err=%v", u, err)
+	}
+}
+
+func TestRouterRegistrationConflicts(t *testing.T) {
+	noop := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	cases := []struct {
+		name    string
+		setup   func(r *Router)
+		wantMsg []string
+	}{
+		{
+			name: "param name conflict",
+			setup: func(r *Router) {
+				r.GET("/users/:id", noop)
+				r.GET("/users/:uid/posts", noop)
+			},
+			wantMsg: []string{"param conflict", "/users/:uid/posts", "/users/:id"},
+		},
+		{
+			name: "constrained param name conflict",
+			setup: func(r *Router) {
+				r.GET("/users/:id<int>", noop)
+				r.POST("/users/:uid<int>", noop)
+			},
+			wantMsg: []string{"param conflict", "/users/:uid<int>", "/users/:id<int>"},
+		},
+		{
+			name: "duplicate route",
+			setup: func(r *Router) {
+				r.GET("/users", noop)
+				r.GET("/users", noop)
+			},
+			wantMsg: []string{"duplicate route GET /users"},
+		},
+		{
+			name: "route under mount",
+			setup: func(r *Router) {
+				r.Mount("/api", noop)
+				r.GET("/api/users", noop)
+			},
+			wantMsg: []string{"/api/users", "shadowed by mount /api"},
+		},
+		{
+			name: "mount over route",
+			setup: func(r *Router) {
+				r.GET("/api", noop)
+				r.Mount("/api", noop)
+			},
+			wantMsg: []string{"mount /api shadows route /api"},
+		},
+		{
+			name: "duplicate mount",
+			setup: func(r *Router) {
+				r.Mount("/api", noop)
+				r.Mount("/api", noop)
+			},
+			wantMsg: []string{"duplicate mount /api"},
+		},
+		{
+			name: "param mount over route",
+			setup: func(r *Router) {
+				r.GET("/tenants/:tid/users", noop)
+				r.Mount("/tenants/:tid", noop)
+			},
+			wantMsg: []string{"mount /tenants/:tid shadows route /tenants/:tid/users"},
+		},
+		{
+			name: "param mount name conflict",
+			setup: func(r *Router) {
+				r.GET("/tenants/:id", noop)
+				r.Mount("/tenants/:tid", noop)
+			},
+			wantMsg: []string{"param conflict", "/tenants/:tid", "/tenants/:id"},
+		},
+		{
+			name: "wildcard mount",
+			setup: func(r *Router) {
+				r.Mount("/files/*path", noop)
+			},
+			wantMsg: []string{"prefix must not contain wildcard"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				rec := recover()
+				msg, ok := rec.(string)
+				if !ok {
+					t.Fatalf("expected string panic, got %v", rec)
+				}
+				for _, want := range tc.wantMsg {
+					if !strings.Contains(msg, want) {
+						t.Fatalf("panic %q does not contain %q", msg, want)
+					}
+				}
+			}()
+			tc.setup(New())
+		})
+	}
+}
+
+func TestRouterNoConflictForDistinctRoutes(t *testing.T) {
+	noop := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	r := New()
+	r.GET("/users/:id", noop)
+	r.POST("/users/:id", noop)
+	r.GET("/users/:id/posts", noop)
+	r.GET("/users/:id<int>/raw", noop)
+	r.Mount("/apix", noop)
+	r.GET("/api", noop)
+}
+
+func TestRouterRadixBacktracking(t *testing.T) {
+	r := New()
+	text := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte(s))
+		})
+	}
+	r.GET("/users/list", text("list"))
+	r.GET("/users/:id/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("x:" + Param(req, "id")))
+	}))
+	r.GET("/search", text("search"))
+	r.GET("/se/:q", text("se"))
+	r.GET("/s", text("s"))
+	r.GET("/:any", text("any"))
+	api := New()
+	api.GET("/", text("api"))
+	v1 := New()
+	v1.GET("/", text("v1"))
+	r.Mount("/api", api)
+	r.Mount("/api/v1", v1)
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{path: "/users/list", want: "list"},
+		{path: "/users/list/x", want: "x:list"},
+		{path: "/search", want: "search"},
+		{path: "/se/go", want: "se"},
+		{path: "/s", want: "s"},
+		{path: "/sea", want: "any"},
+		{path: "/api", want: "api"},
+		{path: "/api/v1", want: "v1"},
+		{path: "/api/v1/", want: "v1"},
+		{path: "/apix", want: "any"},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Body.String() != tc.want {
+			t.Fatalf("%s: got %q (status %d) want %q", tc.path, rec.Body.String(), rec.Code, tc.want)
+		}
+	}
+}
+
+func TestParamsReturnsCopy(t *testing.T) {
+	r := New()
+	var params []RouteParam
+	r.GET("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		params = Params(req)
+	}))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2", nil))
+	first := params
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/3", nil))
+
+	if len(first) != 1 || first[0].Value != "2" {
+		t.Fatalf("params were mutated after handler returned: %+v", first)
+	}
+}
+
+func TestParamsContextOutlivesHandler(t *testing.T) {
+	r := New()
+	var kept []*http.Request
+	r.GET("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		kept = append(kept, req.WithContext(context.WithoutCancel(req.Context())))
+	}))
+
+	for _, id := range []string{"1", "2", "3"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/"+id, nil))
+	}
+	for i, req := range kept {
+		want := strconv.Itoa(i + 1)
+		if got := Param(req, "id"); got != want {
+			t.Fatalf("request %d: context kept after handler returned has id=%q want %q", i, got, want)
+		}
+		if req.Context().Value(struct{}{}) != nil {
+			t.Fatalf("unexpected value in kept context")
+		}
+	}
+}
+
+func TestRouterParamMount(t *testing.T) {
+	root := New()
+	tenant := New()
+	users := New()
+	var tid, id, pattern, original, path string
+	var params []RouteParam
+	users.GET("/:id", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tid = Param(req, "tid")
+		id = Param(req, "id")
+		params = Params(req)
+		pattern = Pattern(req)
+		original = OriginalPath(req)
+		path = req.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	tenant.Mount("/users", users)
+	root.Mount("/tenants/:tid<int>", tenant)
+
+	rec := httptest.NewRecorder()
+	root.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/7/users/42", nil))
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if tid != "7" || id != "42" {
+		t.Fatalf("unexpected params: tid=%q id=%q", tid, id)
+	}
+	if len(params) != 2 || params[0].Key != "tid" || params[1].Key != "id" {
+		t.Fatalf("unexpected params order: %+v", params)
+	}
+	if pattern != "/tenants/:tid<int>/users/:id" {
+		t.Fatalf("unexpected pattern: %q", pattern)
+	}
+	if original != "/tenants/7/users/42" || path != "/42" {
+		t.Fatalf("unexpected paths: original=%q path=%q", original, path)
+	}
+
+	rec = httptest.NewRecorder()
+	root.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/x/users/42", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("constraint mismatch: unexpected status %d", rec.Code)
+	}
+}
+
+func TestRouterParamMountPlainHandler(t *testing.T) {
+	root := New()
+	var tid, original string
+	root.Mount("/tenants/:tid", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tid = Param(req, "tid")
+		original = OriginalPath(req)
+	}))
+
+	root.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tenants/acme/files/a.txt", nil))
+	if tid != "acme" {
+		t.Fatalf("unexpected tid: %q", tid)
+	}
+	if original != "/tenants/acme/files/a.txt" {
+		t.Fatalf("unexpected original path: %q", original)
+	}
+}
+
+func TestRouterParamMountURL(t *testing.T) {
+	root := New()
+	sub := New()
+	sub.HandleNamed("tenant.user", http.MethodGet, "/users/:id", http.NotFoundHandler())
+	root.Mount("/tenants/:tid", sub)
+
+	got, err := root.URL("tenant.user", "tid", "acme", "id", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/tenants/acme/users/1" {
+		t.Fatalf("unexpected url: %q", got)
+	}
+}
+
+func TestRouterParamMountInnerWins(t *testing.T) {
+	root := New()
+	sub := New()
+	var got string
+	sub.GET("/:id", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = Param(req, "id")
+	}))
+	root.Mount("/a/:id", sub)
+
+	root.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a/outer/inner", nil))
+	if got != "inner" {
+		t.Fatalf("expected inner param, got %q", got)
+	}
+}
+
+func TestOriginalPathWithoutMount(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/x/y", nil)
+	if got := OriginalPath(req); got != "/x/y" {
+		t.Fatalf("unexpected original path: %q", got)
+	}
+}
+
+func TestRouterRedirectTrailingSlash(t *testing.T) {
+	r := New(WithRedirectTrailingSlash())
+	noop := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	r.GET("/posts", noop)
+	r.POST("/posts", noop)
+	r.GET("/tags/", noop)
+
+	cases := []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{method: http.MethodGet, path: "/posts/", code: http.StatusMovedPermanently, location: "/posts"},
+		{method: http.MethodGet, path: "/posts/?page=2", code: http.StatusMovedPermanently, location: "/posts?page=2"},
+		{method: http.MethodPost, path: "/posts/", code: http.StatusPermanentRedirect, location: "/posts"},
+		{method: http.MethodGet, path: "/tags", code: http.StatusMovedPermanently, location: "/tags/"},
+		{method: http.MethodGet, path: "/missing/", code: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != tc.code {
+			t.Fatalf("%s %s: unexpected status %d", tc.method, tc.path, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tc.location {
+			t.Fatalf("%s %s: unexpected location %q", tc.method, tc.path, got)
+		}
+	}
+}
+
+func TestRouterRedirectUnderMount(t *testing.T) {
+	root := New()
+	sub := New(WithRedirectTrailingSlash())
+	sub.GET("/users", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	root.Mount("/tenants/:tid", sub)
+
+	rec := httptest.NewRecorder()
+	root.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/7/users/", nil))
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if got := rec.Header().Get("Location"); got != "/tenants/7/users" {
+		t.Fatalf("unexpected location: %q", got)
+	}
+}
+
+func TestRouterCleanPath(t *testing.T) {
+	r := New(WithCleanPath(), WithRedirectTrailingSlash())
+	r.GET("/x", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{path: "/x", code: http.StatusNoContent},
+		{path: "//posts/../x", code: http.StatusMovedPermanently, location: "/x"},
+		{path: "/./x/", code: http.StatusMovedPermanently, location: "/x"},
+		{path: "//evil.example/..//", code: http.StatusMovedPermanently, location: "/"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL.Path = tc.path
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Fatalf("%s: unexpected status %d", tc.path, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tc.location {
+			t.Fatalf("%s: unexpected location %q", tc.path, got)
+		}
+	}
+}
+
+func TestRouterCaseInsensitive(t *testing.T) {
+	r := New(WithCaseInsensitive())
+	var id, pattern string
+	r.GET("/Users/:ID/Posts", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id = Param(req, "ID")
+		pattern = Pattern(req)
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/USERS/AbC/posts", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	if id != "AbC" || pattern != "/Users/:ID/Posts" {
+		t.Fatalf("unexpected param/pattern: %q %q", id, pattern)
+	}
+
+	strict := New()
+	strict.GET("/users", http.NotFoundHandler())
+	rec = httptest.NewRecorder()
+	strict.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/Users", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected case-sensitive match by default, got %d", rec.Code)
+	}
+}
+
+func TestRouterEncodedSlashInParam(t *testing.T) {
+	r := New()
+	var name, rest string
+	r.GET("/files/:name/meta", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name = Param(req, "name")
+	}))
+	sub := New()
+	sub.GET("/:key", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rest = Param(req, "key")
+	}))
+	r.Mount("/kv", sub)
+	var path string
+	r.GET("/Api/*path", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path = Param(req, "path")
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/a%2Fb/meta", nil))
+	if rec.Code != http.StatusOK || name != "a/b" {
+		t.Fatalf("unexpected result: status=%d name=%q", rec.Code, name)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/kv/x%2Fy", nil))
+	if rec.Code != http.StatusOK || rest != "x/y" {
+		t.Fatalf("unexpected mount result: status=%d key=%q", rec.Code, rest)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/%41pi/a%2Fb", nil))
+	if rec.Code != http.StatusOK || path != "a/b" {
+		t.Fatalf("expected fallback to URL.Path: status=%d path=%q", rec.Code, path)
+	}
+}
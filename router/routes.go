@@ -0,0 +1,79 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// Route описывает зарегистрированный маршрут.
+type Route struct {
+	Name    string `json:"name,omitempty"`
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	Mount   string `json:"mount,omitempty"`
+	Handler string `json:"handler"`
+}
+
+// Routes возвращает таблицу маршрутов, включая маршруты под Mount.
+//
+// Pattern содержит полный путь с учётом prefix. Для sub‑handler, который не является
+// Router, возвращается одна запись с методом "*" и pattern prefix + "/*".
+// Имя handler берётся из метода HandlerName() string, если он есть, иначе через reflection.
+// Автоматические HEAD и OPTIONS в таблицу не попадают.
+func (r *Router) Routes() []Route {
+	out := make([]Route, 0)
+	r.cur.Load().collectRoutes("", &out)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Pattern != out[j].Pattern {
+			return out[i].Pattern < out[j].Pattern
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+func (t *table) collectRoutes(prefix string, out *[]Route) {
+	names := make(map[routeName]string, len(t.names))
+	for name, route := range t.names {
+		names[route] = name
+	}
+	walkNodes(t.root, func(n *node) {
+		for method, h := range n.handlers {
+			*out = append(*out, Route{
+				Name:    names[routeName{method: method, pattern: n.pattern}],
+				Method:  method,
+				Pattern: joinPattern(prefix, n.pattern),
+				Mount:   prefix,
+				Handler: handlerName(h),
+			})
+		}
+	})
+	for _, m := range t.mounts {
+		full := joinPattern(prefix, m.prefix)
+		if sub, ok := subRouter(m.handler); ok {
+			sub.cur.Load().collectRoutes(full, out)
+			continue
+		}
+		*out = append(*out, Route{
+			Method:  "*",
+			Pattern: joinPattern(full, "/*"),
+			Mount:   full,
+			Handler: handlerName(m.handler),
+		})
+	}
+}
+
+func handlerName(h http.Handler) string {
+	if named, ok := h.(interface{ HandlerName() string }); ok {
+		return named.HandlerName()
+	}
+	v := reflect.ValueOf(h)
+	if v.Kind() == reflect.Func {
+		if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return reflect.TypeOf(h).String()
+}
@@ -0,0 +1,30 @@
+package router
+
+// errServing — текст panic при изменении Router, который уже обслуживает запросы.
+const errServing = "router: cannot modify a serving router; build a new Router and use Swap"
+
+// mutable возвращает таблицу для регистрации.
+// После первого ServeHTTP или Swap таблица опубликована, и изменение запрещено.
+func (r *Router) mutable() *table {
+	if r.serving.Load() {
+		panic(errServing)
+	}
+	return r.cur.Load()
+}
+
+// Swap атомарно публикует таблицу маршрутов next в r.
+//
+// Запросы, начавшиеся до Swap, завершаются на старой таблице; новые обслуживаются новой.
+// Опции New, NotFound и MethodNotAllowed переходят вместе с таблицей.
+// После Swap next нельзя изменять: собирайте новую таблицу в новом Router.
+func (r *Router) Swap(next *Router) {
+	if next == nil {
+		panic("router: swap with nil router")
+	}
+	if next == r {
+		return
+	}
+	next.serving.Store(true)
+	r.serving.Store(true)
+	r.cur.Store(next.cur.Load())
+}
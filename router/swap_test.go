@@ -0,0 +1,147 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func textHandler(s string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(s))
+	})
+}
+
+func TestRouterSwap(t *testing.T) {
+	live := New()
+	live.GET("/v", textHandler("old"))
+
+	next := New(WithRedirectTrailingSlash())
+	next.GET("/v", textHandler("new"))
+	next.GET("/beta", textHandler("beta"))
+
+	rec := httptest.NewRecorder()
+	live.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v", nil))
+	if rec.Body.String() != "old" {
+		t.Fatalf("unexpected body before swap: %q", rec.Body.String())
+	}
+
+	live.Swap(next)
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{path: "/v", code: http.StatusOK, body: "new"},
+		{path: "/beta", code: http.StatusOK, body: "beta"},
+		{path: "/beta/", code: http.StatusMovedPermanently},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		live.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.code {
+			t.Fatalf("%s: unexpected status %d", tc.path, rec.Code)
+		}
+		if tc.body != "" && rec.Body.String() != tc.body {
+			t.Fatalf("%s: unexpected body %q", tc.path, rec.Body.String())
+		}
+	}
+	if got := live.Routes(); len(got) != 2 {
+		t.Fatalf("expected routes of the new table, got %+v", got)
+	}
+}
+
+func TestRouterSwapInFlight(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	live := New()
+	live.GET("/slow", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(entered)
+		<-release
+		_, _ = w.Write([]byte("old:" + Param(req, "x") + Pattern(req)))
+	}))
+
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		live.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		close(done)
+	}()
+	<-entered
+
+	next := New()
+	next.GET("/slow", textHandler("new"))
+	live.Swap(next)
+	close(release)
+	<-done
+
+	if rec.Body.String() != "old:/slow" {
+		t.Fatalf("in-flight request must finish on the old table, got %q", rec.Body.String())
+	}
+}
+
+func TestRouterModifyServingPanics(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   func(r *Router)
+	}{
+		{name: "handle", fn: func(r *Router) { r.GET("/x", textHandler("x")) }},
+		{name: "mount", fn: func(r *Router) { r.Mount("/m", textHandler("m")) }},
+		{name: "not found", fn: func(r *Router) { r.NotFound(nil) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := New()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, "serving router") {
+					t.Fatalf("expected serving panic, got %q", msg)
+				}
+			}()
+			tc.fn(r)
+		})
+	}
+}
+
+func TestRouterSwappedRouterIsFrozen(t *testing.T) {
+	live := New()
+	next := New()
+	live.Swap(next)
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic on modifying published router")
+		}
+	}()
+	next.GET("/late", textHandler("late"))
+}
+
+func TestRouterSwapConcurrent(t *testing.T) {
+	live := New()
+	live.GET("/v", textHandler("0"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				rec := httptest.NewRecorder()
+				live.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v", nil))
+				if rec.Code != http.StatusOK {
+					t.Errorf("unexpected status: %d", rec.Code)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		next := New()
+		next.GET("/v", textHandler("n"))
+		live.Swap(next)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrUnknownRoute возвращается URL для незарегистрированного имени маршрута.
+	ErrUnknownRoute = errors.New("router: unknown route name")
+	// ErrMissingParam возвращается URL, если не передан параметр маршрута.
+	ErrMissingParam = errors.New("router: missing route param")
+	// ErrInvalidParam возвращается URL, если значение не проходит ограничение параметра.
+	ErrInvalidParam = errors.New("router: invalid route param")
+	// ErrOddParams возвращается URL при нечётном числе аргументов key/value.
+	ErrOddParams = errors.New("router: params must be key/value pairs")
+)
+
+// routeName — маршрут, за которым закреплено имя.
+type routeName struct {
+	method  string
+	pattern string
+}
+
+// HandleNamed регистрирует handler как Handle и связывает с именем пару method + pattern.
+// Имя уникально: его нельзя повторно использовать ни для другого pattern, ни для другого метода.
+func (r *Router) HandleNamed(name, method, pattern string, h http.Handler) {
+	if name == "" {
+		panic("router: empty route name")
+	}
+	t := r.mutable()
+	key := routeName{method: strings.ToUpper(method), pattern: pattern}
+	if _, ok := t.names[name]; ok {
+		panic(fmt.Sprintf("router: duplicate route name %q", name))
+	}
+	r.Handle(method, pattern, h)
+	if t.names == nil {
+		t.names = map[string]routeName{}
+	}
+	t.names[name] = key
+}
+
+// URL строит путь маршрута по имени. params — пары key, value.
+// Значения экранируются как сегменты пути; для *wildcard каждый сегмент экранируется отдельно.
+// Маршруты вложенных Router под Mount ищутся с учётом prefix.
+func (r *Router) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", ErrOddParams
+	}
+	pattern, ok := r.cur.Load().lookupName(name, "")
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRoute, name)
+	}
+	return buildURL(pattern, params)
+}
+
+func (t *table) lookupName(name, prefix string) (string, bool) {
+	if route, ok := t.names[name]; ok {
+		return joinPattern(prefix, route.pattern), true
+	}
+	for _, m := range t.mounts {
+		sub, ok := subRouter(m.handler)
+		if !ok {
+			continue
+		}
+		if pattern, ok := sub.cur.Load().lookupName(name, joinPattern(prefix, m.prefix)); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+func buildURL(pattern string, params []string) (string, error) {
+	segments := splitPath(pattern)
+	if len(segments) == 0 {
+		return "/", nil
+	}
+	out := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			value, ok := lookupValue(params, seg[1:])
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrMissingParam, seg[1:])
+			}
+			if value == "" {
+				continue
+			}
+			parts := strings.Split(value, "/")
+			for i, part := range parts {
+				parts[i] = url.PathEscape(part)
+			}
+			out = append(out, strings.Join(parts, "/"))
+		case strings.HasPrefix(seg, ":"):
+			key, c := parseParam(seg)
+			value, ok := lookupValue(params, key)
+			if !ok || value == "" {
+				return "", fmt.Errorf("%w: %s", ErrMissingParam, key)
+			}
+			if !c.allows(value) {
+				return "", fmt.Errorf("%w: %s", ErrInvalidParam, key)
+			}
+			out = append(out, url.PathEscape(value))
+		default:
+			out = append(out, seg)
+		}
+	}
+	return "/" + strings.Join(out, "/"), nil
+}
+
+func lookupValue(params []string, key string) (string, bool) {
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i] == key {
+			return params[i+1], true
+		}
+	}
+	return "", false
+}
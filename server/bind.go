@@ -0,0 +1,373 @@
+package server
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	apperrors "github.com/sejta/nope/errors"
+	jsonkit "github.com/sejta/nope/json"
+	"github.com/sejta/nope/router"
+)
+
+var errInvalidRequestType = errors.New("server: typed request must be a struct")
+
+type bindSource int
+
+const (
+	sourcePath bindSource = iota
+	sourceQuery
+	sourceHeader
+)
+
+var bindTags = [...]string{sourcePath: "path", sourceQuery: "query", sourceHeader: "header"}
+
+type bindField struct {
+	index  []int
+	source bindSource
+	name   string
+}
+
+// bindPlan описывает, откуда заполняются поля запроса. Строится один раз при регистрации.
+type bindPlan struct {
+	fields []bindField
+	body   bool      // есть поля без path/query/header, их читает JSON-тело
+	view   *bodyView // тип для разбора тела; nil — тело разбирается прямо в запрос
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	rawMessageType      = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// newBindPlan собирает поля с тегами, в том числе из встроенных структур
+// (по правилам encoding/json: без json-имени и не "-").
+func newBindPlan(t reflect.Type, routePath string) (bindPlan, error) {
+	var plan bindPlan
+	if t.Kind() != reflect.Struct {
+		return plan, fmt.Errorf("%w: %s", errInvalidRequestType, t)
+	}
+	if err := plan.collect(t, nil, routePath, []reflect.Type{t}); err != nil {
+		return plan, err
+	}
+	if len(plan.fields) > 0 && !reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		plan.view = newBodyView(t, []reflect.Type{t})
+	}
+	return plan, nil
+}
+
+func (p *bindPlan) collect(t reflect.Type, index []int, routePath string, path []reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		f.Index = append(slices.Clone(index), i)
+		if et, ok := embeddedStruct(f); ok {
+			if slices.Contains(path, et) {
+				continue
+			}
+			n := len(p.fields)
+			if err := p.collect(et, f.Index, routePath, append(path, et)); err != nil {
+				return err
+			}
+			if len(p.fields) > n && f.Type.Kind() == reflect.Pointer && !f.IsExported() {
+				return fmt.Errorf("%w: %s.%s: bind tags in unexported embedded pointer", errInvalidRoute, t, f.Name)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		field, tagged, err := bindFieldOf(f, routePath)
+		if err != nil {
+			return fmt.Errorf("%w: %s.%s: %v", errInvalidRoute, t, f.Name, err)
+		}
+		if tagged {
+			p.fields = append(p.fields, field)
+			continue
+		}
+		if f.Tag.Get("json") != "-" {
+			p.body = true
+		}
+	}
+	return nil
+}
+
+// embeddedStruct возвращает тип встроенной структуры, поля которой encoding/json
+// поднимает во внешний объект.
+func embeddedStruct(f reflect.StructField) (reflect.Type, bool) {
+	if !f.Anonymous {
+		return nil, false
+	}
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+		return nil, false
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct
+}
+
+// bodyView — тип, в который разбирается тело: копия структуры запроса, где поля
+// с тегами path/query/header принимают любое JSON-значение и отбрасываются,
+// а встроенные структуры заменены такими же копиями. Имена полей и теги те же,
+// поэтому правила encoding/json (регистр, встраивание, строгий режим) не меняются.
+type bodyView struct {
+	typ    reflect.Type
+	fields []viewField
+}
+
+type viewField struct {
+	index int // поле исходной структуры; -1 — значение отбрасывается
+	embed *bodyView
+	ptr   bool
+}
+
+func newBodyView(t reflect.Type, path []reflect.Type) *bodyView {
+	v := &bodyView{}
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if et, ok := embeddedStruct(f); ok {
+			ptr := f.Type.Kind() == reflect.Pointer
+			if slices.Contains(path, et) || (ptr && !f.IsExported()) {
+				continue
+			}
+			inner := newBodyView(et, append(path, et))
+			ft := inner.typ
+			if ptr {
+				ft = reflect.PointerTo(ft)
+			}
+			name := f.Name
+			if !f.IsExported() {
+				name = "Embedded" + strconv.Itoa(i)
+			}
+			fields = append(fields, reflect.StructField{Name: name, Type: ft, Tag: f.Tag, Anonymous: true})
+			v.fields = append(v.fields, viewField{index: i, embed: inner, ptr: ptr})
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		sf, vf := reflect.StructField{Name: f.Name, Type: f.Type, Tag: f.Tag}, viewField{index: i}
+		if isBound(f) {
+			sf.Type, vf.index = rawMessageType, -1
+		}
+		fields = append(fields, sf)
+		v.fields = append(v.fields, vf)
+	}
+	v.typ = reflect.StructOf(fields)
+	return v
+}
+
+// copyTo переносит разобранные значения из src (тип view) в dst.
+func (v *bodyView) copyTo(dst, src reflect.Value) {
+	for i, f := range v.fields {
+		if f.index < 0 {
+			continue
+		}
+		sv, dv := src.Field(i), dst.Field(f.index)
+		switch {
+		case f.embed == nil:
+			dv.Set(sv)
+		case !f.ptr:
+			f.embed.copyTo(dv, sv)
+		case !sv.IsNil():
+			if dv.IsNil() {
+				dv.Set(reflect.New(dv.Type().Elem()))
+			}
+			f.embed.copyTo(dv.Elem(), sv.Elem())
+		}
+	}
+}
+
+func bindFieldOf(f reflect.StructField, routePath string) (bindField, bool, error) {
+	for source, tag := range bindTags {
+		name, ok := f.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		field := bindField{index: f.Index, source: bindSource(source), name: name}
+		if name == "" {
+			return field, true, fmt.Errorf("empty %s tag", tag)
+		}
+		if field.source == sourcePath && !hasPathParam(routePath, name) {
+			return field, true, fmt.Errorf("path param %q is not in %s", name, routePath)
+		}
+		if !bindable(f.Type, field.source != sourcePath) {
+			return field, true, fmt.Errorf("unsupported type %s", f.Type)
+		}
+		return field, true, nil
+	}
+	return bindField{}, false, nil
+}
+
+func hasPathParam(routePath, name string) bool {
+	for _, seg := range strings.Split(routePath, "/") {
+		if !strings.HasPrefix(seg, ":") && !strings.HasPrefix(seg, "*") {
+			continue
+		}
+		seg = seg[1:]
+		if i := strings.IndexByte(seg, '<'); i >= 0 {
+			seg = seg[:i]
+		}
+		if seg == name {
+			return true
+		}
+	}
+	return false
+}
+
+func bindable(t reflect.Type, allowSlice bool) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Pointer:
+		return bindable(t.Elem(), false)
+	case reflect.Slice:
+		return allowSlice && bindable(t.Elem(), false)
+	default:
+		return false
+	}
+}
+
+// bind заполняет dst из JSON-тела, path, query и header.
+// Поля с тегами path/query/header из тела не заполняются, даже если совпадает имя.
+// Ошибки преобразования собираются в один AppError 400 с fields.
+func (p bindPlan) bind(r *http.Request, dst reflect.Value) error {
+	if p.body && hasBody(r) {
+		if p.view == nil {
+			if err := jsonkit.DecodeJSON(r, dst.Addr().Interface()); err != nil {
+				return err
+			}
+		} else {
+			view := reflect.New(p.view.typ)
+			if err := jsonkit.DecodeJSON(r, view.Interface()); err != nil {
+				return err
+			}
+			p.view.copyTo(dst, view.Elem())
+		}
+	}
+
+	var query url.Values
+	var fields map[string]string
+	for _, f := range p.fields {
+		var values []string
+		switch f.source {
+		case sourcePath:
+			if v := router.Param(r, f.name); v != "" {
+				values = []string{v}
+			}
+		case sourceQuery:
+			if query == nil {
+				query = r.URL.Query()
+			}
+			values = query[f.name]
+		case sourceHeader:
+			values = r.Header.Values(f.name)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if reason := setField(fieldByIndexAlloc(dst, f.index), values); reason != "" {
+			if fields == nil {
+				fields = map[string]string{}
+			}
+			fields[f.name] = reason
+		}
+	}
+	if fields != nil {
+		return apperrors.WithFields(apperrors.E(http.StatusBadRequest, CodeInvalidRequest, MsgInvalidRequest), fields)
+	}
+	return nil
+}
+
+// fieldByIndexAlloc как FieldByIndex, но создаёт nil-указатели на встроенные структуры.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+// setField записывает values в поле и возвращает причину ошибки для fields.
+func setField(v reflect.Value, values []string) string {
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		out := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, one := range values {
+			if reason := setScalar(out.Index(i), one); reason != "" {
+				return reason
+			}
+		}
+		v.Set(out)
+		return ""
+	}
+	return setScalar(v, values[0])
+}
+
+func setScalar(v reflect.Value, raw string) string {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(raw)); err != nil {
+			return "invalid value"
+		}
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		ptr := reflect.New(v.Type().Elem())
+		if reason := setScalar(ptr.Elem(), raw); reason != "" {
+			return reason
+		}
+		v.Set(ptr)
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "must be a boolean"
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return "must be an integer"
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return "must be a non-negative integer"
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return "must be a number"
+		}
+		v.SetFloat(n)
+	}
+	return ""
+}
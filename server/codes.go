@@ -0,0 +1,8 @@
+package server
+
+const (
+	// CodeInvalidRequest — код ошибки привязки path/query/header к типизированному запросу.
+	CodeInvalidRequest = "invalid_request"
+	// MsgInvalidRequest — сообщение об ошибке привязки запроса.
+	MsgInvalidRequest = "invalid request"
+)
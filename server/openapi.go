@@ -0,0 +1,249 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sejta/nope/router"
+)
+
+const (
+	openAPIPath    = "/openapi.json"
+	openAPIVersion = "3.1.0"
+	errorSchemaRef = "#/components/schemas/Error"
+	errorRespRef   = "#/components/responses/Error"
+)
+
+// OpenAPIInfo описывает блок info документа OpenAPI.
+type OpenAPIInfo struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+// EnableOpenAPI включает маршрут GET /openapi.json с документом OpenAPI 3.1.
+// Документ строится один раз в Handler по зарегистрированным маршрутам.
+func (s *Server) EnableOpenAPI(info OpenAPIInfo) {
+	s.enableOpenAPI = true
+	s.openAPIInfo = info
+}
+
+// OpenAPI возвращает документ OpenAPI 3.1 в JSON по зарегистрированным маршрутам.
+//
+// Params берутся из pattern (с учётом ограничений), query/header и тело — из типов
+// server.Handle или опций RequestBody/ResponseBody. Ответы 4XX/5XX описаны схемой error contract.
+func (s *Server) OpenAPI() ([]byte, error) {
+	if len(s.buildErrs) > 0 {
+		return nil, errors.Join(s.buildErrs...)
+	}
+	info := s.openAPIInfo
+	if info.Title == "" {
+		info.Title = "API"
+	}
+	if info.Version == "" {
+		info.Version = "0.0.0"
+	}
+
+	gen := newSchemaGen()
+	paths := map[string]map[string]any{}
+	for _, route := range s.r.Routes() {
+		if route.Method == "*" {
+			continue
+		}
+		p := openAPIPathOf(route.Pattern)
+		if paths[p] == nil {
+			paths[p] = map[string]any{}
+		}
+		paths[p][strings.ToLower(route.Method)] = s.operation(gen, route)
+	}
+
+	infoDoc := map[string]any{"title": info.Title, "version": info.Version}
+	if info.Description != "" {
+		infoDoc["description"] = info.Description
+	}
+	gen.schemas["Error"] = errorSchema()
+	doc := map[string]any{
+		"openapi": openAPIVersion,
+		"info":    infoDoc,
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": gen.schemas,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Error contract",
+					"content":     jsonContent(map[string]any{"$ref": errorSchemaRef}),
+				},
+			},
+		},
+	}
+	return json.Marshal(doc)
+}
+
+func (s *Server) addRouteDoc(method, pattern string, ro routeOptions) {
+	if s.docs == nil {
+		s.docs = map[string]routeOptions{}
+	}
+	s.docs[strings.ToUpper(method)+" "+pattern] = ro
+}
+
+func (s *Server) operation(gen *schemaGen, route router.Route) map[string]any {
+	ro := s.docs[route.Method+" "+route.Pattern]
+	op := map[string]any{}
+	if route.Name != "" {
+		op["operationId"] = route.Name
+	}
+	if ro.summary != "" {
+		op["summary"] = ro.summary
+	}
+	if len(ro.tags) > 0 {
+		op["tags"] = ro.tags
+	}
+
+	plan := ro.plan
+	if plan == nil && ro.request != nil {
+		if p, err := newBindPlan(derefType(ro.request), route.Pattern); err == nil {
+			plan = &p
+		}
+	}
+	if params := parameters(gen, route.Pattern, ro.request, plan); len(params) > 0 {
+		op["parameters"] = params
+	}
+	if ro.request != nil && (plan == nil || plan.body) && route.Method != http.MethodGet && route.Method != http.MethodHead {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  jsonContent(gen.bodySchema(ro.request)),
+		}
+	}
+
+	status := ro.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if status != http.StatusNoContent && ro.response != nil && ro.response.Kind() != reflect.Interface {
+		success["content"] = jsonContent(gen.schema(ro.response))
+	}
+	op["responses"] = map[string]any{
+		strconv.Itoa(status): success,
+		"4XX":                map[string]any{"$ref": errorRespRef},
+		"5XX":                map[string]any{"$ref": errorRespRef},
+	}
+	return op
+}
+
+// parameters возвращает path-параметры из pattern и query/header из плана привязки.
+func parameters(gen *schemaGen, pattern string, req reflect.Type, plan *bindPlan) []any {
+	typed := map[string]reflect.Type{}
+	var extra []any
+	if plan != nil && req != nil {
+		req = derefType(req)
+		for _, f := range plan.fields {
+			ft := req.FieldByIndex(f.index).Type
+			if f.source == sourcePath {
+				typed[f.name] = ft
+				continue
+			}
+			extra = append(extra, map[string]any{
+				"name":   f.name,
+				"in":     bindTags[f.source],
+				"schema": gen.schema(ft),
+			})
+		}
+	}
+
+	var out []any
+	for _, seg := range strings.Split(pattern, "/") {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name, constraint := seg[1:], ""
+		if i := strings.IndexByte(name, '<'); i >= 0 {
+			name, constraint = name[:i], strings.TrimSuffix(name[i+1:], ">")
+		}
+		schema := constraintSchema(constraint)
+		if ft, ok := typed[name]; ok {
+			schema = gen.schema(ft)
+		}
+		out = append(out, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+	return append(out, extra...)
+}
+
+func constraintSchema(c string) map[string]any {
+	switch c {
+	case "":
+		return map[string]any{"type": "string"}
+	case "int":
+		return map[string]any{"type": "integer"}
+	case "uint":
+		return map[string]any{"type": "integer", "minimum": 0}
+	case "uuid":
+		return map[string]any{"type": "string", "format": "uuid"}
+	default:
+		return map[string]any{"type": "string", "pattern": "^(?:" + c + ")$"}
+	}
+}
+
+// openAPIPathOf переводит /posts/:id<int>/*rest в /posts/{id}/{rest}.
+func openAPIPathOf(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name := seg[1:]
+		if j := strings.IndexByte(name, '<'); j >= 0 {
+			name = name[:j]
+		}
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/")
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+func errorSchema() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"error"},
+		"properties": map[string]any{
+			"error": map[string]any{
+				"type":     "object",
+				"required": []string{"code", "message"},
+				"properties": map[string]any{
+					"code":    map[string]any{"type": "string"},
+					"message": map[string]any{"type": "string"},
+					"fields": map[string]any{
+						"type":                 "object",
+						"additionalProperties": map[string]any{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func withOpenAPI(h http.Handler, doc []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == openAPIPath && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			if req.Method == http.MethodGet {
+				_, _ = w.Write(doc)
+			}
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
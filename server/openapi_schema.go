@@ -0,0 +1,199 @@
+package server
+
+import (
+	"encoding"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaGen строит JSON Schema по Go-типам. Именованные структуры попадают
+// в components/schemas и подключаются через $ref, что поддерживает рекурсию.
+type schemaGen struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+func newSchemaGen() *schemaGen {
+	return &schemaGen{
+		schemas: map[string]any{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// bodySchema возвращает схему тела запроса: поля с тегами path/query/header исключаются.
+func (g *schemaGen) bodySchema(t reflect.Type) map[string]any {
+	t = derefType(t)
+	if t.Kind() == reflect.Struct && hasBindTags(t) {
+		return g.structSchema(t, true)
+	}
+	return g.schema(t)
+}
+
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	t = derefType(t)
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32:
+		return map[string]any{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, false)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.schemaName(t)
+			g.names[t] = name
+			g.schemas[name] = map[string]any{}
+			g.schemas[name] = g.structSchema(t, false)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// schemaName возвращает уникальное имя схемы. Аргументы generic-типа входят в имя
+// (Page[User] → Page_User); при совпадении имён из разных пакетов добавляется путь пакета,
+// а если занято и оно — числовой суффикс.
+func (g *schemaGen) schemaName(t reflect.Type) string {
+	name := sanitizeTypeName(t.Name())
+	if g.nameFree(name) {
+		return name
+	}
+	name = strings.NewReplacer("/", "_", ".", "_").Replace(t.PkgPath()) + "_" + name
+	for i, base := 2, name; !g.nameFree(name); i++ {
+		name = base + "_" + strconv.Itoa(i)
+	}
+	return name
+}
+
+func (g *schemaGen) nameFree(name string) bool {
+	_, taken := g.schemas[name]
+	return !taken && name != "Error"
+}
+
+// sanitizeTypeName отбрасывает пути пакетов в аргументах generic-типа и заменяет
+// скобки и разделители на "_": "Page[example.com/api.User]" → "Page_User".
+func sanitizeTypeName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return strings.ContainsRune("[]*, ", r)
+	}) {
+		if i := strings.LastIndexByte(part, '.'); i >= 0 {
+			part = part[i+1:]
+		}
+		if b.Len() > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+func (g *schemaGen) structSchema(t reflect.Type, skipBound bool) map[string]any {
+	props := map[string]any{}
+	var required []string
+	g.collectFields(t, skipBound, props, &required)
+	out := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// collectFields повторяет правила encoding/json: json-теги, omitempty, "-" и встроенные структуры.
+func (g *schemaGen) collectFields(t reflect.Type, skipBound bool, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if skipBound && isBound(f) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			if ft := derefType(f.Type); ft.Kind() == reflect.Struct {
+				g.collectFields(ft, skipBound, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func isBound(f reflect.StructField) bool {
+	for _, tag := range bindTags {
+		if _, ok := f.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasBindTags(t reflect.Type) bool {
+	return hasBindTagsIn(t, []reflect.Type{t})
+}
+
+func hasBindTagsIn(t reflect.Type, path []reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isBound(f) {
+			return true
+		}
+		if et, ok := embeddedStruct(f); ok && !slices.Contains(path, et) && hasBindTagsIn(et, append(path, et)) {
+			return true
+		}
+	}
+	return false
+}
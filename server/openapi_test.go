@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type openAPIAuthor struct {
+	Name   string         `json:"name"`
+	Editor *openAPIAuthor `json:"editor,omitempty"`
+}
+
+type openAPIPost struct {
+	ID     int64         `json:"id"`
+	Title  string        `json:"title"`
+	Author openAPIAuthor `json:"author"`
+	Note   string        `json:"note,omitempty"`
+	Secret string        `json:"-"`
+}
+
+type openAPIPage[T any] struct {
+	Items []T `json:"items"`
+}
+
+func openAPIDoc(t *testing.T, s *Server) map[string]any {
+	t.Helper()
+	raw, err := s.OpenAPI()
+	if err != nil {
+		t.Fatalf("openapi failed: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	return doc
+}
+
+func lookup(t *testing.T, v any, keys ...string) any {
+	t.Helper()
+	for _, key := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("not an object at %q", key)
+		}
+		v, ok = m[key]
+		if !ok {
+			t.Fatalf("missing key %q", key)
+		}
+	}
+	return v
+}
+
+func TestOpenAPITypedRoute(t *testing.T) {
+	s := New(":0")
+	tenants := s.Group("/tenants/:tid")
+	Handle(tenants, http.MethodPut, "/posts/:id<int>", updatePost, Summary("Update post"), Tags("posts"), Name("posts.update"))
+
+	doc := openAPIDoc(t, s)
+	if doc["openapi"] != "3.1.0" {
+		t.Fatalf("openapi=%v", doc["openapi"])
+	}
+	op := lookup(t, doc, "paths", "/tenants/{tid}/posts/{id}", "put").(map[string]any)
+	if op["summary"] != "Update post" || op["operationId"] != "posts.update" {
+		t.Fatalf("unexpected operation meta: %v", op)
+	}
+	if tags := op["tags"].([]any); len(tags) != 1 || tags[0] != "posts" {
+		t.Fatalf("tags=%v", tags)
+	}
+
+	params := op["parameters"].([]any)
+	want := map[string]string{"tid": "path", "id": "path", "limit": "query", "tag": "query", "X-Trace": "header"}
+	if len(params) != len(want) {
+		t.Fatalf("params=%v", params)
+	}
+	for _, p := range params {
+		p := p.(map[string]any)
+		if want[p["name"].(string)] != p["in"] {
+			t.Fatalf("unexpected param %v", p)
+		}
+		if p["name"] == "id" && lookup(t, p, "schema", "type") != "integer" {
+			t.Fatalf("id schema=%v", p["schema"])
+		}
+	}
+
+	body := lookup(t, op, "requestBody", "content", "application/json", "schema", "properties").(map[string]any)
+	if len(body) != 1 || body["title"] == nil {
+		t.Fatalf("request body properties=%v", body)
+	}
+	ref := lookup(t, op, "responses", "200", "content", "application/json", "schema", "$ref")
+	if ref != "#/components/schemas/updatePostResponse" {
+		t.Fatalf("response ref=%v", ref)
+	}
+	for _, code := range []string{"4XX", "5XX"} {
+		if lookup(t, op, "responses", code, "$ref") != "#/components/responses/Error" {
+			t.Fatalf("%s is not error contract", code)
+		}
+	}
+	if lookup(t, doc, "components", "responses", "Error", "content", "application/json", "schema", "$ref") != "#/components/schemas/Error" {
+		t.Fatalf("error response must reference Error schema")
+	}
+	lookup(t, doc, "components", "schemas", "Error", "properties", "error", "properties", "code")
+}
+
+func TestOpenAPIUntypedRouteOptions(t *testing.T) {
+	s := New(":0")
+	s.POST("/posts", func(ctx context.Context, r *http.Request) (any, error) {
+		return nil, nil
+	}, RequestBody(openAPIPost{}), ResponseBody(openAPIPost{}), Status(http.StatusCreated))
+	s.DELETE("/posts/:id", func(ctx context.Context, r *http.Request) (any, error) {
+		return nil, nil
+	}, Status(http.StatusNoContent))
+
+	doc := openAPIDoc(t, s)
+	create := lookup(t, doc, "paths", "/posts", "post")
+	if lookup(t, create, "requestBody", "content", "application/json", "schema", "$ref") != "#/components/schemas/openAPIPost" {
+		t.Fatalf("request body must reference openAPIPost")
+	}
+	lookup(t, create, "responses", "201", "content")
+
+	post := lookup(t, doc, "components", "schemas", "openAPIPost").(map[string]any)
+	props := post["properties"].(map[string]any)
+	if _, ok := props["Secret"]; ok {
+		t.Fatalf("json:\"-\" field must be skipped")
+	}
+	if required := post["required"].([]any); len(required) != 3 {
+		t.Fatalf("required=%v", required)
+	}
+	if lookup(t, props, "author", "$ref") != "#/components/schemas/openAPIAuthor" {
+		t.Fatalf("author must be a ref")
+	}
+	if lookup(t, doc, "components", "schemas", "openAPIAuthor", "properties", "editor", "$ref") != "#/components/schemas/openAPIAuthor" {
+		t.Fatalf("recursive type must reference itself")
+	}
+
+	remove := lookup(t, doc, "paths", "/posts/{id}", "delete")
+	if _, ok := lookup(t, remove, "responses", "204").(map[string]any)["content"]; ok {
+		t.Fatalf("204 must not have content")
+	}
+}
+
+func TestOpenAPIGenericSchemaNames(t *testing.T) {
+	s := New(":0")
+	noop := func(ctx context.Context, r *http.Request) (any, error) { return nil, nil }
+	s.GET("/posts", noop, ResponseBody(openAPIPage[openAPIPost]{}))
+	s.GET("/authors", noop, ResponseBody(openAPIPage[openAPIAuthor]{}))
+	s.GET("/drafts", noop, ResponseBody(openAPIPage[*openAPIPost]{}))
+	s.GET("/archive", noop, ResponseBody(openAPIPage[[]openAPIPost]{}))
+
+	doc := openAPIDoc(t, s)
+	refs := map[string]bool{}
+	for _, path := range []string{"/posts", "/authors", "/drafts", "/archive"} {
+		ref := lookup(t, doc, "paths", path, "get", "responses", "200", "content", "application/json", "schema", "$ref").(string)
+		if refs[ref] {
+			t.Fatalf("%s: schema name %s reused", path, ref)
+		}
+		refs[ref] = true
+	}
+	schemas := lookup(t, doc, "components", "schemas").(map[string]any)
+	for _, name := range []string{"openAPIPage_openAPIPost", "openAPIPage_openAPIAuthor"} {
+		if _, ok := schemas[name]; !ok {
+			t.Fatalf("missing schema %s in %v", name, schemas)
+		}
+	}
+	if lookup(t, schemas, "openAPIPage_openAPIAuthor", "properties", "items", "items", "$ref") != "#/components/schemas/openAPIAuthor" {
+		t.Fatalf("page items must reference openAPIAuthor")
+	}
+}
+
+func TestOpenAPIOperationIDPerMethod(t *testing.T) {
+	s := New(":0")
+	noop := func(ctx context.Context, r *http.Request) (any, error) { return nil, nil }
+	s.GET("/users/:id", noop, Name("users.get"))
+	s.PUT("/users/:id", noop, Name("users.update"))
+	s.DELETE("/users/:id", noop, Name("users.delete"))
+
+	doc := openAPIDoc(t, s)
+	for method, want := range map[string]string{"get": "users.get", "put": "users.update", "delete": "users.delete"} {
+		if got := lookup(t, doc, "paths", "/users/{id}", method, "operationId"); got != want {
+			t.Fatalf("%s: operationId=%v want %s", method, got, want)
+		}
+	}
+
+	s = New(":0")
+	s.GET("/users/:id", noop, Name("users"))
+	s.PUT("/users/:id", noop, Name("users"))
+	if err := s.Validate(); err == nil {
+		t.Fatalf("expected error for name reused on another method")
+	}
+}
+
+func TestOpenAPIRoute(t *testing.T) {
+	s := New(":0")
+	s.GET("/ping", func(ctx context.Context, r *http.Request) (any, error) {
+		return "pong", nil
+	})
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("openapi must be disabled by default, status=%d", rr.Code)
+	}
+
+	s.EnableOpenAPI(OpenAPIInfo{Title: "Blog", Version: "1.2.0"})
+	h, err = s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d", rr.Code)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if lookup(t, doc, "info", "title") != "Blog" || lookup(t, doc, "info", "version") != "1.2.0" {
+		t.Fatalf("info=%v", doc["info"])
+	}
+	lookup(t, doc, "paths", "/ping", "get")
+}
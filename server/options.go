@@ -0,0 +1,81 @@
+package server
+
+import "reflect"
+
+// RouteOption задаёт дополнительные параметры регистрации маршрута.
+type RouteOption func(*routeOptions)
+
+type routeOptions struct {
+	name     string
+	summary  string
+	tags     []string
+	status   int
+	request  reflect.Type
+	response reflect.Type
+	plan     *bindPlan
+}
+
+// Name задаёт имя маршрута для построения URL через Server.URL.
+// В OpenAPI имя становится operationId.
+func Name(name string) RouteOption {
+	return func(o *routeOptions) {
+		o.name = name
+	}
+}
+
+// Summary задаёт краткое описание маршрута для OpenAPI.
+func Summary(summary string) RouteOption {
+	return func(o *routeOptions) {
+		o.summary = summary
+	}
+}
+
+// Tags задаёт теги маршрута для OpenAPI.
+func Tags(tags ...string) RouteOption {
+	return func(o *routeOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// Status задаёт код успешного ответа для OpenAPI (по умолчанию 200).
+func Status(code int) RouteOption {
+	return func(o *routeOptions) {
+		o.status = code
+	}
+}
+
+// RequestBody задаёт тип тела запроса для OpenAPI по значению-образцу: RequestBody(CreatePost{}).
+// Для server.Handle тип берётся из Req автоматически.
+func RequestBody(v any) RouteOption {
+	return func(o *routeOptions) {
+		o.request = reflect.TypeOf(v)
+		o.plan = nil
+	}
+}
+
+// ResponseBody задаёт тип успешного ответа для OpenAPI по значению-образцу.
+// Для server.Handle тип берётся из Resp автоматически.
+func ResponseBody(v any) RouteOption {
+	return func(o *routeOptions) {
+		o.response = reflect.TypeOf(v)
+	}
+}
+
+// typedRoute передаёт в метаданные маршрута типы и план привязки server.Handle.
+func typedRoute(req, resp reflect.Type, plan *bindPlan) RouteOption {
+	return func(o *routeOptions) {
+		o.request = req
+		o.response = resp
+		o.plan = plan
+	}
+}
+
+func buildRouteOptions(opts []RouteOption) routeOptions {
+	var out routeOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&out)
+		}
+	}
+	return out
+}
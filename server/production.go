@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sejta/nope/app"
+	apperrors "github.com/sejta/nope/errors"
+	"github.com/sejta/nope/httpkit/middleware"
+	"github.com/sejta/nope/internal/routeinfo"
+	jsonkit "github.com/sejta/nope/json"
+	"github.com/sejta/nope/obs"
+)
+
+// Переменные окружения PresetProduction (дополнительно к app.ConfigFromEnv).
+const (
+	EnvRequestTimeout = "NOPE_REQUEST_TIMEOUT"
+	EnvMaxBodyBytes   = "NOPE_MAX_BODY_BYTES"
+	EnvCORSOrigins    = "NOPE_CORS_ORIGINS"
+	EnvAccessLog      = "NOPE_ACCESS_LOG"
+	EnvAdminAddr      = "NOPE_ADMIN_ADDR"
+)
+
+const defaultRequestTimeout = 5 * time.Second
+
+// ProductionOptions задаёт параметры PresetProduction.
+// Нулевые поля берутся из окружения, затем из дефолтов.
+type ProductionOptions struct {
+	// RequestTimeout — deadline обработки запроса (NOPE_REQUEST_TIMEOUT, по умолчанию 5s).
+	RequestTimeout time.Duration
+	// MaxBodyBytes — лимит тела запроса (NOPE_MAX_BODY_BYTES, по умолчанию 1 MiB).
+	MaxBodyBytes int64
+	// CORSOrigins — разрешённые origins (NOPE_CORS_ORIGINS через запятую); пусто — CORS выключен.
+	CORSOrigins []string
+	// Logger получает события завершения запросов и panic (по умолчанию текст в stderr).
+	Logger obs.Logger
+	// Metrics получает метрики запросов; nil — без метрик.
+	Metrics obs.Metrics
+	// AccessLog включает access log middleware (NOPE_ACCESS_LOG=1 — в stdout).
+	AccessLog middleware.Logger
+	// AdminAddr выносит health, readiness и отладку на отдельный listener (NOPE_ADMIN_ADDR).
+	AdminAddr string
+}
+
+// NewProduction создаёт Server с PresetProduction и указанными опциями.
+// Ошибки разбора окружения возвращаются из Validate/Run.
+func NewProduction(addr string, opts ProductionOptions) *Server {
+	s := NewWithPreset(addr, PresetMinimal)
+	s.applyProduction(addr, opts)
+	return s
+}
+
+func (s *Server) applyProduction(addr string, opts ProductionOptions) {
+	cfg, err := app.ConfigFromEnv(s.cfg)
+	s.setBuildErr(err)
+	if addr != "" {
+		cfg.Addr = addr
+	}
+	s.addr = cfg.Addr
+
+	opts, err = productionFromEnv(opts)
+	s.setBuildErr(err)
+	if opts.Logger == nil {
+		opts.Logger = obs.NewTextLogger(os.Stderr)
+	}
+	cfg.Hooks = obs.NewHooks(opts.Logger, opts.Metrics)
+	s.cfg = cfg
+
+	s.Use(recoverOutsideRun(opts.Logger), obs.Wrap, middleware.RequestID, middleware.AccessLog(opts.AccessLog))
+	if len(opts.CORSOrigins) > 0 {
+		s.EnableCORS(middleware.CORSOptions{AllowedOrigins: opts.CORSOrigins})
+	}
+	s.Use(
+		middleware.MaxBodyBytes(opts.MaxBodyBytes),
+		middleware.Timeout(opts.RequestTimeout),
+		middleware.TimeoutError(middleware.DefaultTimeoutError),
+	)
+	s.EnableHealth()
+	s.EnableReadiness()
+	if opts.AdminAddr != "" {
+		s.EnableAdmin(opts.AdminAddr)
+	}
+}
+
+// recoverOutsideRun перехватывает panic, когда s.Handler() встроен в чужой сервер:
+// логирует её в logger и отвечает 500. Под Run panic доходит до hooks app
+// (их признак — routeinfo в контексте), которые делают то же самое.
+func recoverOutsideRun(logger obs.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if routeinfo.FromContext(r.Context()) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			defer func() {
+				if rec := recover(); rec != nil {
+					logger.LogPanic(r.Context(), obs.PanicEvent{
+						Method: r.Method,
+						Path:   r.URL.Path,
+						ReqID:  w.Header().Get("X-Request-Id"),
+						Value:  rec,
+					})
+					apperrors.WriteError(w, r, apperrors.E(http.StatusInternalServerError, apperrors.CodeInternal, apperrors.MsgInternal))
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func productionFromEnv(opts ProductionOptions) (ProductionOptions, error) {
+	if opts.RequestTimeout == 0 {
+		opts.RequestTimeout = defaultRequestTimeout
+		if err := app.EnvDuration(EnvRequestTimeout, &opts.RequestTimeout); err != nil {
+			return opts, err
+		}
+	}
+	if opts.MaxBodyBytes == 0 {
+		opts.MaxBodyBytes = jsonkit.DefaultMaxBodyBytes
+		if v := os.Getenv(EnvMaxBodyBytes); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("server: invalid %s=%q: expected positive integer", EnvMaxBodyBytes, v)
+			}
+			opts.MaxBodyBytes = n
+		}
+	}
+	if len(opts.CORSOrigins) == 0 {
+		for _, origin := range strings.Split(os.Getenv(EnvCORSOrigins), ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				opts.CORSOrigins = append(opts.CORSOrigins, origin)
+			}
+		}
+	}
+	if opts.AdminAddr == "" {
+		opts.AdminAddr = os.Getenv(EnvAdminAddr)
+	}
+	if opts.AccessLog == nil {
+		if v := os.Getenv(EnvAccessLog); v != "" {
+			on, err := strconv.ParseBool(v)
+			if err != nil {
+				return opts, fmt.Errorf("server: invalid %s=%q: expected boolean", EnvAccessLog, v)
+			}
+			if on {
+				opts.AccessLog = log.New(os.Stdout, "", log.LstdFlags)
+			}
+		}
+	}
+	return opts, nil
+}
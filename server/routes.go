@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+
+	"github.com/sejta/nope/httpkit"
+	"github.com/sejta/nope/router"
+)
+
+const routesDebugPath = "/debug/routes"
+
+type routesPayload struct {
+	Routes []router.Route `json:"routes"`
+}
+
+// namedHandler сохраняет имя исходного httpkit.Handler для router.Routes.
+type namedHandler struct {
+	http.Handler
+	name string
+}
+
+func (h namedHandler) HandlerName() string {
+	return h.name
+}
+
+func funcName(h any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+func withRoutesDebug(h http.Handler, r *router.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == routesDebugPath && req.Method == http.MethodGet {
+			httpkit.JSON(w, http.StatusOK, routesPayload{Routes: r.Routes()})
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
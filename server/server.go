@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/sejta/nope/app"
+	apperrors "github.com/sejta/nope/errors"
 	"github.com/sejta/nope/httpkit"
 	"github.com/sejta/nope/httpkit/middleware"
 	"github.com/sejta/nope/router"
@@ -20,10 +21,14 @@ var (
 	errTrailingPrefix = errors.New("server: prefix must not end with /")
 	errInvalidRoute   = errors.New("server: invalid route registration")
 	errInvalidCORS    = errors.New("server: invalid cors options")
+	errNilCheck       = errors.New("server: readiness check is nil")
+	errEmptyAdminAddr = errors.New("server: admin address is empty")
 )
 
 // Middleware описывает HTTP middleware в формате net/http.
-type Middleware func(http.Handler) http.Handler
+type Middleware = router.Middleware
+
+const adminListener = "admin"
 
 // Preset определяет готовый набор настроек фасада.
 type Preset int
@@ -33,6 +38,10 @@ const (
 	PresetMinimal Preset = iota
 	// PresetDefault включает минимально полезный набор middleware.
 	PresetDefault
+	// PresetProduction включает базовый набор для продакшена: obs hooks и Wrap, RequestID,
+	// AccessLog, CORS, лимит тела, Timeout с ответом по контракту, /healthz и /readyz.
+	// Параметры берутся из окружения, см. ProductionOptions и app.ConfigFromEnv.
+	PresetProduction
 )
 
 // Server предоставляет упрощённый API для регистрации роутов и запуска сервера.
@@ -41,16 +50,56 @@ type Server struct {
 	r                 *router.Router
 	cfg               app.Config
 	globalMiddleware  []Middleware
+	workers           []app.Worker
+	readiness         *app.Readiness
+	listeners         []app.Listener
+	adminAddr         string
 	enableHealthRoute bool
 	enablePprofRoute  bool
-	buildErr          error
+	enableRoutesDebug bool
+	enableOpenAPI     bool
+	openAPIInfo       OpenAPIInfo
+	docs              map[string]routeOptions
+	buildErrs         []error
 }
 
 // Group объединяет роуты с общим prefix и локальными middleware.
+// Построена поверх router.Group: middleware выполняются после матчинга.
 type Group struct {
 	s      *Server
 	prefix string
-	mws    []Middleware
+	rg     *router.Group
+}
+
+// registrar — общий интерфейс регистрации router.Router и router.Group.
+type registrar interface {
+	Handle(method, pattern string, h http.Handler)
+	HandleNamed(name, method, pattern string, h http.Handler)
+	Mount(prefix string, h http.Handler)
+}
+
+// target — куда регистрируется маршрут: router и prefix группы для полного pattern.
+type target struct {
+	reg    registrar
+	prefix string
+}
+
+func (t target) fullPath(routePath string) string {
+	if t.prefix == "" {
+		return routePath
+	}
+	if p, err := joinPaths(t.prefix, routePath); err == nil {
+		return p
+	}
+	return routePath
+}
+
+func (s *Server) target() target {
+	return target{reg: s.r}
+}
+
+func (g *Group) target() target {
+	return target{reg: g.rg, prefix: g.prefix}
 }
 
 // New создаёт новый Server с минимальным preset.
@@ -71,6 +120,10 @@ func NewWithPreset(addr string, preset Preset) *Server {
 		cfg:  cfg,
 	}
 
+	if preset == PresetProduction {
+		s.applyProduction(addr, ProductionOptions{})
+		return s
+	}
 	s.applyPreset(preset)
 	return s
 }
@@ -98,16 +151,98 @@ func (s *Server) Use(mw ...Middleware) {
 	}
 }
 
+// AddWorker регистрирует фоновые задачи, которые живут вместе с сервером.
+// Workers стартуют после bind listener и отменяются при остановке, см. app.Worker.
+func (s *Server) AddWorker(w ...app.Worker) {
+	for _, one := range w {
+		if one == nil {
+			continue
+		}
+		s.workers = append(s.workers, one)
+	}
+}
+
+// EnableAdmin переносит health, readiness, pprof и /debug/routes на отдельный
+// listener addr (например "127.0.0.1:9090"); публичный Handler их больше не обслуживает.
+func (s *Server) EnableAdmin(addr string) {
+	if addr == "" {
+		s.setBuildErr(errEmptyAdminAddr)
+		return
+	}
+	s.adminAddr = addr
+}
+
+// AddListener добавляет именованный listener со своим handler и hooks.
+// Остановка общая с основным сервером.
+func (s *Server) AddListener(l app.Listener) {
+	if l.Handler == nil {
+		s.setBuildErr(errNilHandler)
+		return
+	}
+	s.listeners = append(s.listeners, l)
+}
+
 // EnableHealth включает стандартный маршрут GET /healthz.
 func (s *Server) EnableHealth() {
 	s.enableHealthRoute = true
 }
 
+// EnableReadiness включает маршрут GET /readyz с отчётом проверок готовности.
+// При остановке /readyz отвечает 503 в течение Config().DrainTimeout до Shutdown.
+func (s *Server) EnableReadiness() {
+	if s.readiness == nil {
+		s.readiness = app.NewReadiness()
+	}
+}
+
+// AddReadinessCheck регистрирует проверку готовности и включает /readyz.
+// timeout <= 0 означает app.DefaultCheckTimeout.
+func (s *Server) AddReadinessCheck(name string, check app.Check, timeout time.Duration) {
+	if check == nil {
+		s.setBuildErr(errNilCheck)
+		return
+	}
+	s.EnableReadiness()
+	s.readiness.Add(name, check, timeout)
+}
+
 // EnablePprof включает маршруты /debug/pprof/*.
 func (s *Server) EnablePprof() {
 	s.enablePprofRoute = true
 }
 
+// EnableRoutesDebug включает маршрут GET /debug/routes с таблицей маршрутов в JSON.
+func (s *Server) EnableRoutesDebug() {
+	s.enableRoutesDebug = true
+}
+
+// Routes возвращает таблицу зарегистрированных маршрутов.
+func (s *Server) Routes() []router.Route {
+	return s.r.Routes()
+}
+
+// NotFound задаёт хендлер для 404 по контракту httpkit.Handler.
+// Без него роутер отвечает JSON-ошибкой not_found.
+func (s *Server) NotFound(h httpkit.Handler) {
+	fn, err := httpkit.TryAdapt(h)
+	if err != nil {
+		s.setBuildErr(errNilHandler)
+		return
+	}
+	s.r.NotFound(fn)
+}
+
+// MethodNotAllowed задаёт хендлер для 405 по контракту httpkit.Handler.
+// Заголовок Allow уже выставлен к моменту вызова.
+func (s *Server) MethodNotAllowed(h httpkit.Handler) {
+	fn, err := httpkit.TryAdapt(h)
+	if err != nil {
+		s.setBuildErr(errNilHandler)
+		return
+	}
+	s.r.MethodNotAllowed(fn)
+}
+
 // EnableCORS добавляет глобальный CORS middleware с указанной политикой.
 func (s *Server) EnableCORS(opts middleware.CORSOptions) {
 	mw, err := buildCORSMiddleware(opts)
@@ -120,35 +255,63 @@ func (s *Server) EnableCORS(opts middleware.CORSOptions) {
 
 // Group создаёт группу роутов с общим prefix.
 func (s *Server) Group(prefix string) *Group {
+	g := &Group{s: s, prefix: prefix, rg: s.r.With()}
 	if err := validatePrefix(prefix); err != nil {
 		s.setBuildErr(err)
+		return g
 	}
-	return &Group{s: s, prefix: prefix}
+	s.r.Group(prefix, func(rg *router.Group) {
+		g.rg = rg
+	})
+	return g
 }
 
 // GET регистрирует GET-хендлер по контракту httpkit.Handler.
-func (s *Server) GET(routePath string, h httpkit.Handler) {
-	s.handle(http.MethodGet, routePath, h, nil)
+func (s *Server) GET(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	s.handle(s.target(), http.MethodGet, routePath, h, opts)
 }
 
 // POST регистрирует POST-хендлер по контракту httpkit.Handler.
-func (s *Server) POST(routePath string, h httpkit.Handler) {
-	s.handle(http.MethodPost, routePath, h, nil)
+func (s *Server) POST(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	s.handle(s.target(), http.MethodPost, routePath, h, opts)
 }
 
 // PUT регистрирует PUT-хендлер по контракту httpkit.Handler.
-func (s *Server) PUT(routePath string, h httpkit.Handler) {
-	s.handle(http.MethodPut, routePath, h, nil)
+func (s *Server) PUT(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	s.handle(s.target(), http.MethodPut, routePath, h, opts)
 }
 
 // PATCH регистрирует PATCH-хендлер по контракту httpkit.Handler.
-func (s *Server) PATCH(routePath string, h httpkit.Handler) {
-	s.handle(http.MethodPatch, routePath, h, nil)
+func (s *Server) PATCH(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	s.handle(s.target(), http.MethodPatch, routePath, h, opts)
 }
 
 // DELETE регистрирует DELETE-хендлер по контракту httpkit.Handler.
-func (s *Server) DELETE(routePath string, h httpkit.Handler) {
-	s.handle(http.MethodDelete, routePath, h, nil)
+func (s *Server) DELETE(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	s.handle(s.target(), http.MethodDelete, routePath, h, opts)
+}
+
+// Method регистрирует хендлер на произвольный метод (например, PURGE).
+// HEAD и OPTIONS обслуживаются роутером автоматически.
+func (s *Server) Method(method, routePath string, h httpkit.Handler, opts ...RouteOption) {
+	s.handle(s.target(), method, routePath, h, opts)
+}
+
+// Handle регистрирует http.Handler на метод и путь без адаптера httpkit.
+// Подходит для сторонних handler; ошибки регистрации копятся до Validate.
+func (s *Server) Handle(method, routePath string, h http.Handler, opts ...RouteOption) {
+	s.register(s.target(), method, routePath, h, opts)
+}
+
+// Mount монтирует http.Handler (file server, reverse proxy) на prefix.
+// Sub-handler получает путь без prefix.
+func (s *Server) Mount(prefix string, h http.Handler) {
+	s.mount(s.r, prefix, h)
+}
+
+// URL строит путь именованного маршрута. params — пары key, value.
+func (s *Server) URL(name string, params ...string) (string, error) {
+	return s.r.URL(name, params...)
 }
 
 // Run запускает HTTP-сервер с context.Background().
@@ -169,10 +332,23 @@ func (s *Server) RunContext(ctx context.Context) error {
 	if cfg.Addr == "" {
 		cfg.Addr = s.addr
 	}
+	cfg.Workers = append(append([]app.Worker(nil), cfg.Workers...), s.workers...)
+	if cfg.Readiness == nil {
+		cfg.Readiness = s.readiness
+	}
+	cfg.Listeners = append(append([]app.Listener(nil), cfg.Listeners...), s.listeners...)
+	if s.adminAddr != "" {
+		admin, err := s.AdminHandler()
+		if err != nil {
+			return err
+		}
+		cfg.Listeners = append(cfg.Listeners, app.Listener{Name: adminListener, Addr: s.adminAddr, Handler: admin})
+	}
 	return app.Run(ctx, cfg, h)
 }
 
 // Validate проверяет конфигурацию фасада до запуска.
+// Возвращает все накопленные ошибки регистрации, включая конфликты маршрутов.
 func (s *Server) Validate() error {
 	_, err := s.Handler()
 	return err
@@ -180,66 +356,144 @@ func (s *Server) Validate() error {
 
 // Handler собирает итоговый http.Handler с учётом middleware и app-обёрток.
 func (s *Server) Handler() (http.Handler, error) {
-	if s.buildErr != nil {
-		return nil, s.buildErr
+	if len(s.buildErrs) > 0 {
+		return nil, errors.Join(s.buildErrs...)
 	}
 
 	var h http.Handler = s.r
 	h = applyMiddleware(h, s.globalMiddleware)
+	if s.adminAddr == "" {
+		h = s.withServiceRoutes(h)
+	}
+	if s.enableOpenAPI {
+		doc, err := s.OpenAPI()
+		if err != nil {
+			return nil, err
+		}
+		h = withOpenAPI(h, doc)
+	}
+	return h, nil
+}
+
+// AdminHandler собирает handler admin‑порта: health, readiness, pprof и /debug/routes.
+// Без EnableAdmin эти маршруты обслуживает Handler.
+func (s *Server) AdminHandler() (http.Handler, error) {
+	if len(s.buildErrs) > 0 {
+		return nil, errors.Join(s.buildErrs...)
+	}
+	return s.withServiceRoutes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apperrors.WriteError(w, r, apperrors.E(http.StatusNotFound, apperrors.CodeNotFound, apperrors.MsgNotFound))
+	})), nil
+}
+
+// withServiceRoutes добавляет служебные маршруты, включённые через Enable*.
+func (s *Server) withServiceRoutes(h http.Handler) http.Handler {
 	if s.enableHealthRoute {
 		h = app.WithHealth(h)
 	}
+	h = app.WithReadiness(h, s.readiness)
 	if s.enablePprofRoute {
 		h = app.WithPprof(h)
 	}
-	return h, nil
+	if s.enableRoutesDebug {
+		h = withRoutesDebug(h, s.r)
+	}
+	return h
+}
+
+// Group создаёт вложенную группу: prefix дописывается к prefix родителя,
+// middleware родителя на момент вызова наследуются.
+func (g *Group) Group(prefix string) *Group {
+	child := &Group{s: g.s, prefix: g.prefix, rg: g.rg.With()}
+	if err := validatePrefix(g.prefix); err != nil {
+		return child
+	}
+	if err := validatePrefix(prefix); err != nil {
+		g.s.setBuildErr(err)
+		return child
+	}
+	child.prefix = joinPrefix(g.prefix, prefix)
+	g.rg.Group(prefix, func(rg *router.Group) {
+		child.rg = rg
+	})
+	return child
 }
 
 // Use добавляет middleware только для текущей группы.
 func (g *Group) Use(mw ...Middleware) {
-	for _, one := range mw {
-		if one == nil {
-			continue
-		}
-		g.mws = append(g.mws, one)
-	}
+	g.rg.Use(mw...)
 }
 
 // GET регистрирует GET-хендлер в группе.
-func (g *Group) GET(routePath string, h httpkit.Handler) {
-	g.handle(http.MethodGet, routePath, h)
+func (g *Group) GET(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	g.handle(http.MethodGet, routePath, h, opts)
 }
 
 // POST регистрирует POST-хендлер в группе.
-func (g *Group) POST(routePath string, h httpkit.Handler) {
-	g.handle(http.MethodPost, routePath, h)
+func (g *Group) POST(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	g.handle(http.MethodPost, routePath, h, opts)
 }
 
 // PUT регистрирует PUT-хендлер в группе.
-func (g *Group) PUT(routePath string, h httpkit.Handler) {
-	g.handle(http.MethodPut, routePath, h)
+func (g *Group) PUT(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	g.handle(http.MethodPut, routePath, h, opts)
 }
 
 // PATCH регистрирует PATCH-хендлер в группе.
-func (g *Group) PATCH(routePath string, h httpkit.Handler) {
-	g.handle(http.MethodPatch, routePath, h)
+func (g *Group) PATCH(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	g.handle(http.MethodPatch, routePath, h, opts)
 }
 
 // DELETE регистрирует DELETE-хендлер в группе.
-func (g *Group) DELETE(routePath string, h httpkit.Handler) {
-	g.handle(http.MethodDelete, routePath, h)
+func (g *Group) DELETE(routePath string, h httpkit.Handler, opts ...RouteOption) {
+	g.handle(http.MethodDelete, routePath, h, opts)
 }
 
-func (g *Group) handle(method, routePath string, h httpkit.Handler) {
-	fullPath, err := joinPaths(g.prefix, routePath)
-	if err != nil {
+// Method регистрирует хендлер на произвольный метод в группе.
+func (g *Group) Method(method, routePath string, h httpkit.Handler, opts ...RouteOption) {
+	g.handle(method, routePath, h, opts)
+}
+
+// Handle регистрирует http.Handler в группе без адаптера httpkit.
+func (g *Group) Handle(method, routePath string, h http.Handler, opts ...RouteOption) {
+	if _, err := joinPaths(g.prefix, routePath); err != nil {
+		g.s.setBuildErr(err)
+		return
+	}
+	g.s.register(g.target(), method, routePath, h, opts)
+}
+
+// Mount монтирует http.Handler на prefix внутри группы; middleware группы применяются.
+func (g *Group) Mount(prefix string, h http.Handler) {
+	if err := validatePrefix(g.prefix); err != nil {
+		g.s.setBuildErr(err)
+		return
+	}
+	g.s.mount(g.rg, prefix, h)
+}
+
+func (g *Group) handle(method, routePath string, h httpkit.Handler, opts []RouteOption) {
+	if _, err := joinPaths(g.prefix, routePath); err != nil {
 		g.s.setBuildErr(err)
 		return
 	}
-	g.s.handle(method, fullPath, h, g.mws)
+	g.s.handle(g.target(), method, routePath, h, opts)
 }
 
-func (s *Server) handle(method, routePath string, h httpkit.Handler, local []Middleware) {
+func (s *Server) handle(t target, method, routePath string, h httpkit.Handler, opts []RouteOption) {
+	if h == nil {
+		s.setBuildErr(errNilHandler)
+		return
+	}
+	s.handleNamed(t, method, routePath, h, funcName(h), opts)
+}
+
+// handleNamed регистрирует httpkit.Handler под именем name в таблице маршрутов.
+func (s *Server) handleNamed(t target, method, routePath string, h httpkit.Handler, name string, opts []RouteOption) {
+	s.register(t, method, routePath, namedHandler{Handler: httpkit.Adapt(h), name: name}, opts)
+}
+
+func (s *Server) register(t target, method, routePath string, h http.Handler, opts []RouteOption) {
 	if h == nil {
 		s.setBuildErr(errNilHandler)
 		return
@@ -248,23 +502,49 @@ func (s *Server) handle(method, routePath string, h httpkit.Handler, local []Mid
 		s.setBuildErr(err)
 		return
 	}
+	ro := buildRouteOptions(opts)
+	if err := safeHandle(t.reg, ro.name, method, routePath, h); err != nil {
+		s.setBuildErr(err)
+		return
+	}
+	s.addRouteDoc(method, t.fullPath(routePath), ro)
+}
 
-	httpHandler := http.Handler(httpkit.Adapt(h))
-	if len(local) > 0 {
-		httpHandler = applyMiddleware(httpHandler, local)
+func (s *Server) mount(reg registrar, prefix string, h http.Handler) {
+	if h == nil {
+		s.setBuildErr(errNilHandler)
+		return
 	}
-	if err := s.safeHandle(method, routePath, httpHandler); err != nil {
+	if err := validatePrefix(prefix); err != nil {
 		s.setBuildErr(err)
+		return
 	}
+	if err := safeMount(reg, prefix, h); err != nil {
+		s.setBuildErr(err)
+	}
+}
+
+func safeHandle(reg registrar, name, method, routePath string, h http.Handler) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = errors.Join(errInvalidRoute, panicCauseErr(rec))
+		}
+	}()
+	if name != "" {
+		reg.HandleNamed(name, method, routePath, h)
+		return nil
+	}
+	reg.Handle(method, routePath, h)
+	return nil
 }
 
-func (s *Server) safeHandle(method, routePath string, h http.Handler) (err error) {
+func safeMount(reg registrar, prefix string, h http.Handler) (err error) {
 	defer func() {
 		if rec := recover(); rec != nil {
 			err = errors.Join(errInvalidRoute, panicCauseErr(rec))
 		}
 	}()
-	s.r.Handle(method, routePath, h)
+	reg.Mount(prefix, h)
 	return nil
 }
 
@@ -280,10 +560,10 @@ func panicCauseErr(rec any) error {
 }
 
 func (s *Server) setBuildErr(err error) {
-	if err == nil || s.buildErr != nil {
+	if err == nil {
 		return
 	}
-	s.buildErr = err
+	s.buildErrs = append(s.buildErrs, err)
 }
 
 func (s *Server) applyPreset(preset Preset) {
@@ -331,6 +611,16 @@ func validatePath(routePath string) error {
 	return nil
 }
 
+func joinPrefix(parent, prefix string) string {
+	if parent == "/" {
+		return prefix
+	}
+	if prefix == "/" {
+		return parent
+	}
+	return parent + prefix
+}
+
 func joinPaths(prefix, routePath string) (string, error) {
 	if err := validatePrefix(prefix); err != nil {
 		return "", err
@@ -3,12 +3,21 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/sejta/nope/app"
+	apperrors "github.com/sejta/nope/errors"
 	"github.com/sejta/nope/httpkit/middleware"
+	"github.com/sejta/nope/obs"
+	"github.com/sejta/nope/router"
 )
 
 func TestServerGETAndJSON(t *testing.T) {
@@ -115,6 +124,29 @@ func TestGroupMiddlewareAppliedOnlyToGroup(t *testing.T) {
 	}
 }
 
+func TestGroupMiddlewareSeesRouteParams(t *testing.T) {
+	s := New(":0")
+	api := s.Group("/api")
+	var pattern, id string
+	api.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern = router.Pattern(r)
+			id = router.Param(r, "id")
+			next.ServeHTTP(w, r)
+		})
+	})
+	api.GET("/users/:id", pingHandler)
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/5", nil))
+	if pattern != "/api/users/:id" || id != "5" {
+		t.Fatalf("pattern=%q id=%q", pattern, id)
+	}
+}
+
 func TestGlobalMiddlewareOrder(t *testing.T) {
 	s := New(":0")
 	s.Use(
@@ -224,6 +256,165 @@ func TestPresetDefaultAddsRequestID(t *testing.T) {
 	}
 }
 
+func TestPresetProductionFromEnv(t *testing.T) {
+	t.Setenv(EnvRequestTimeout, "20ms")
+	t.Setenv(EnvMaxBodyBytes, "4")
+	t.Setenv(EnvCORSOrigins, "https://app.example, https://admin.example")
+	t.Setenv("NOPE_SHUTDOWN_TIMEOUT", "12s")
+
+	s := NewWithPreset(":0", PresetProduction)
+	s.Handle(http.MethodGet, "/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	s.POST("/echo", func(ctx context.Context, r *http.Request) (any, error) {
+		return nil, nil
+	})
+	s.GET("/ping", func(ctx context.Context, r *http.Request) (any, error) {
+		return "pong", nil
+	})
+	if s.Config().Addr != ":0" || s.Config().ShutdownTimeout != 12*time.Second {
+		t.Fatalf("config from env not applied: %+v", s.Config())
+	}
+	if s.Config().Hooks.OnRequestEnd == nil {
+		t.Fatalf("obs hooks not installed")
+	}
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if rr.Code != http.StatusGatewayTimeout || !strings.Contains(rr.Body.String(), apperrors.CodeTimeout) {
+		t.Fatalf("timeout: status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("X-Request-Id") == "" {
+		t.Fatalf("missing request id header")
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"a":1}`)))
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body limit: status=%d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("healthz: status=%d", rr.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Origin", "https://admin.example")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "https://admin.example" {
+		t.Fatalf("cors origins from env not applied")
+	}
+}
+
+type panicLogger struct {
+	mu     sync.Mutex
+	panics []obs.PanicEvent
+}
+
+func (l *panicLogger) LogRequestEnd(context.Context, obs.RequestEndEvent) {}
+
+func (l *panicLogger) LogPanic(ctx context.Context, e obs.PanicEvent) {
+	l.mu.Lock()
+	l.panics = append(l.panics, e)
+	l.mu.Unlock()
+}
+
+func (l *panicLogger) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.panics)
+}
+
+func TestPresetProductionRecoversPanic(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	logger := &panicLogger{}
+	s := NewProduction(addr, ProductionOptions{Logger: logger})
+	s.Handle(http.MethodGet, "/boom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	// Handler, встроенный в чужой сервер, тоже отвечает 500 и логирует panic.
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), apperrors.CodeInternal) {
+		t.Fatalf("panic outside Run: status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if logger.count() != 1 || logger.panics[0].ReqID == "" || logger.panics[0].ReqID != rr.Header().Get("X-Request-Id") {
+		t.Fatalf("panic outside Run must be logged once with request id: %+v", logger.panics)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.RunContext(ctx)
+	}()
+
+	client := &http.Client{Timeout: time.Second}
+	var resp *http.Response
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err = client.Get("http://" + addr + "/boom")
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server not reachable: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || !strings.Contains(string(body), apperrors.CodeInternal) {
+		t.Fatalf("panic: status=%d body=%s", resp.StatusCode, body)
+	}
+	if logger.count() != 2 {
+		t.Fatalf("panic under Run must be logged once by hooks, got %d events", logger.count())
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("run did not stop")
+	}
+}
+
+func TestPresetProductionInvalidEnv(t *testing.T) {
+	t.Setenv(EnvMaxBodyBytes, "lots")
+
+	s := NewProduction(":0", ProductionOptions{})
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), EnvMaxBodyBytes) {
+		t.Fatalf("expected %s error, got %v", EnvMaxBodyBytes, err)
+	}
+
+	s = NewProduction(":0", ProductionOptions{MaxBodyBytes: 1 << 10})
+	if err := s.Validate(); err != nil {
+		t.Fatalf("explicit option must win over env: %v", err)
+	}
+}
+
 func TestEnableCORSAddsHeadersForAllowedOrigin(t *testing.T) {
 	s := New(":0")
 	s.EnableCORS(middleware.CORSOptions{
@@ -289,3 +480,414 @@ func TestValidateReturnsBuildError(t *testing.T) {
 		t.Fatalf("expected router panic reason in error, got %q", err.Error())
 	}
 }
+
+func TestMethodCustomAndAutoHead(t *testing.T) {
+	s := New(":0")
+	s.Method("PURGE", "/cache", func(ctx context.Context, r *http.Request) (any, error) {
+		return map[string]bool{"purged": true}, nil
+	})
+	s.GET("/ping", func(ctx context.Context, r *http.Request) (any, error) {
+		return map[string]bool{"pong": true}, nil
+	})
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("PURGE", "/cache", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d", rr.Code, http.StatusOK)
+	}
+
+	rrHead := httptest.NewRecorder()
+	h.ServeHTTP(rrHead, httptest.NewRequest(http.MethodHead, "/ping", nil))
+	if rrHead.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d", rrHead.Code, http.StatusOK)
+	}
+	if rrHead.Body.Len() != 0 {
+		t.Fatalf("unexpected body for HEAD: %q", rrHead.Body.String())
+	}
+}
+
+func TestEnableRoutesDebug(t *testing.T) {
+	s := New(":0")
+	s.EnableRoutesDebug()
+	s.GET("/ping", pingHandler)
+	api := s.Group("/api")
+	api.POST("/users/:id", pingHandler)
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d", rr.Code, http.StatusOK)
+	}
+
+	var payload struct {
+		Routes []struct {
+			Method  string `json:"method"`
+			Pattern string `json:"pattern"`
+			Handler string `json:"handler"`
+		} `json:"routes"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("json decode failed: %v", err)
+	}
+	if len(payload.Routes) != 2 {
+		t.Fatalf("routes=%v want 2", payload.Routes)
+	}
+	if payload.Routes[0].Pattern != "/api/users/:id" || payload.Routes[0].Method != http.MethodPost {
+		t.Fatalf("unexpected route: %+v", payload.Routes[0])
+	}
+	if !strings.HasSuffix(payload.Routes[1].Handler, "server.pingHandler") {
+		t.Fatalf("handler=%q want suffix server.pingHandler", payload.Routes[1].Handler)
+	}
+}
+
+func TestRoutesDebugDisabledByDefault(t *testing.T) {
+	s := New(":0")
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("status=%d want=%d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func pingHandler(ctx context.Context, r *http.Request) (any, error) {
+	return map[string]bool{"pong": true}, nil
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	s := New(":0")
+	s.NotFound(func(ctx context.Context, r *http.Request) (any, error) {
+		return nil, apperrors.E(http.StatusNotFound, "route_missing", "no such route")
+	})
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("status=%d want=%d", rr.Code, http.StatusNotFound)
+	}
+	if !strings.Contains(rr.Body.String(), `"code":"route_missing"`) {
+		t.Fatalf("unexpected body: %q", rr.Body.String())
+	}
+}
+
+func TestMethodNotAllowedDefaultContract(t *testing.T) {
+	s := New(":0")
+	s.GET("/ping", pingHandler)
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/ping", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status=%d want=%d", rr.Code, http.StatusMethodNotAllowed)
+	}
+	if !strings.Contains(rr.Header().Get("Allow"), http.MethodGet) {
+		t.Fatalf("allow=%q want GET", rr.Header().Get("Allow"))
+	}
+	if !strings.Contains(rr.Body.String(), `"code":"method_not_allowed"`) {
+		t.Fatalf("unexpected body: %q", rr.Body.String())
+	}
+}
+
+func TestNotFoundNilHandlerSetsBuildError(t *testing.T) {
+	s := New(":0")
+	s.NotFound(nil)
+
+	if err := s.Validate(); err == nil {
+		t.Fatalf("expected build error")
+	}
+}
+
+func TestNamedRouteURLInGroup(t *testing.T) {
+	s := New(":0")
+	api := s.Group("/api")
+	api.GET("/posts/:id", pingHandler, Name("posts.get"))
+	s.GET("/ping", pingHandler, Name("ping"))
+
+	if err := s.Validate(); err != nil {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+	got, err := s.URL("posts.get", "id", "42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/api/posts/42" {
+		t.Fatalf("url=%q want=%q", got, "/api/posts/42")
+	}
+	if got, _ := s.URL("ping"); got != "/ping" {
+		t.Fatalf("url=%q want=%q", got, "/ping")
+	}
+}
+
+func TestDuplicateRouteNameSetsBuildError(t *testing.T) {
+	s := New(":0")
+	s.GET("/a", pingHandler, Name("dup"))
+	s.GET("/b", pingHandler, Name("dup"))
+
+	err := s.Validate()
+	if err == nil || !strings.Contains(err.Error(), "router: duplicate route name") {
+		t.Fatalf("expected duplicate name error, got %v", err)
+	}
+}
+
+func TestValidateReturnsAllRouteConflicts(t *testing.T) {
+	s := New(":0")
+	s.GET("/users/:id", pingHandler)
+	s.GET("/users/:uid/posts", pingHandler)
+	s.GET("/ping", pingHandler)
+	s.GET("/ping", pingHandler)
+
+	err := s.Validate()
+	if err == nil {
+		t.Fatalf("expected build error")
+	}
+	for _, want := range []string{"/users/:uid/posts", "/users/:id", "duplicate route GET /ping"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+}
+
+func headerMiddleware(key, value string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add(key, value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestNestedGroupInheritsMiddleware(t *testing.T) {
+	s := New(":0")
+	api := s.Group("/api")
+	api.Use(headerMiddleware("X-Zone", "api"))
+	v1 := api.Group("/v1")
+	v1.Use(headerMiddleware("X-Zone", "v1"))
+	v1.GET("/users", pingHandler)
+	api.GET("/status", pingHandler)
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d want=%d", rr.Code, http.StatusOK)
+	}
+	if got := strings.Join(rr.Header().Values("X-Zone"), ","); got != "api,v1" {
+		t.Fatalf("X-Zone=%q want=%q", got, "api,v1")
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if got := strings.Join(rr.Header().Values("X-Zone"), ","); got != "api" {
+		t.Fatalf("sub-group middleware leaked to parent: X-Zone=%q", got)
+	}
+}
+
+func TestHandleAndMountRawHandlers(t *testing.T) {
+	s := New(":0")
+	s.Handle(http.MethodGet, "/raw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	s.Mount("/static", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("static:" + r.URL.Path))
+	}))
+	admin := s.Group("/admin")
+	admin.Use(headerMiddleware("X-Admin", "1"))
+	admin.Handle(http.MethodPost, "/purge", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	admin.Mount("/files", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("files:" + r.URL.Path))
+	}))
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+		admin  bool
+	}{
+		{method: http.MethodGet, path: "/raw", code: http.StatusAccepted},
+		{method: http.MethodGet, path: "/static/css/app.css", code: http.StatusOK, body: "static:/css/app.css"},
+		{method: http.MethodPost, path: "/admin/purge", code: http.StatusNoContent, admin: true},
+		{method: http.MethodGet, path: "/admin/files/a.txt", code: http.StatusOK, body: "files:/a.txt", admin: true},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+		if rr.Code != tc.code {
+			t.Fatalf("%s: status=%d want=%d", tc.path, rr.Code, tc.code)
+		}
+		if tc.body != "" && rr.Body.String() != tc.body {
+			t.Fatalf("%s: body=%q want=%q", tc.path, rr.Body.String(), tc.body)
+		}
+		if tc.admin != (rr.Header().Get("X-Admin") == "1") {
+			t.Fatalf("%s: unexpected X-Admin=%q", tc.path, rr.Header().Get("X-Admin"))
+		}
+	}
+}
+
+func TestHandleAndMountBuildErrors(t *testing.T) {
+	s := New(":0")
+	s.Handle(http.MethodGet, "/nil", nil)
+	s.Mount("static/", http.NotFoundHandler())
+	s.Mount("/dup", http.NotFoundHandler())
+	s.Mount("/dup", http.NotFoundHandler())
+	s.Group("/api").Group("v1")
+
+	err := s.Validate()
+	if err == nil {
+		t.Fatalf("expected build error")
+	}
+	for _, want := range []error{errNilHandler, errInvalidPrefix, errInvalidRoute} {
+		if !errors.Is(err, want) {
+			t.Fatalf("error %q does not wrap %q", err, want)
+		}
+	}
+	if !strings.Contains(err.Error(), "duplicate mount /dup") {
+		t.Fatalf("error %q does not mention duplicate mount", err)
+	}
+}
+
+func TestServerRunStopsWorkers(t *testing.T) {
+	s := New("127.0.0.1:0")
+	stopped := make(chan struct{})
+	started := make(chan struct{})
+	s.AddWorker(app.WorkerFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- s.RunContext(ctx)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("worker not started")
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("run did not stop")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Fatalf("worker must stop before Run returns")
+	}
+}
+
+func TestAddReadinessCheckEnablesReadyz(t *testing.T) {
+	s := New(":0")
+	s.AddReadinessCheck("db", func(ctx context.Context) error {
+		return errors.New("down")
+	}, time.Second)
+	s.AddReadinessCheck("nil", nil, 0)
+	if err := s.Validate(); !errors.Is(err, errNilCheck) {
+		t.Fatalf("expected nil check error, got %v", err)
+	}
+
+	s = New(":0")
+	s.AddReadinessCheck("db", func(ctx context.Context) error {
+		return errors.New("down")
+	}, time.Second)
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), `"db"`) {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestEnableAdminMovesServiceRoutes(t *testing.T) {
+	s := New(":0")
+	s.EnableHealth()
+	s.EnablePprof()
+	s.EnableRoutesDebug()
+	s.EnableAdmin("127.0.0.1:9090")
+	s.GET("/ping", func(ctx context.Context, r *http.Request) (any, error) {
+		return "pong", nil
+	})
+
+	public, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+	admin, err := s.AdminHandler()
+	if err != nil {
+		t.Fatalf("admin handler build failed: %v", err)
+	}
+
+	cases := []struct {
+		path   string
+		public int
+		admin  int
+	}{
+		{"/ping", http.StatusOK, http.StatusNotFound},
+		{"/healthz", http.StatusNotFound, http.StatusOK},
+		{"/debug/pprof/", http.StatusNotFound, http.StatusOK},
+		{"/debug/routes", http.StatusNotFound, http.StatusOK},
+	}
+	for _, tc := range cases {
+		for _, h := range []struct {
+			name string
+			h    http.Handler
+			want int
+		}{{"public", public, tc.public}, {"admin", admin, tc.admin}} {
+			rr := httptest.NewRecorder()
+			h.h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rr.Code != h.want {
+				t.Fatalf("%s %s: status=%d want=%d", h.name, tc.path, rr.Code, h.want)
+			}
+		}
+	}
+
+	s.EnableAdmin("")
+	if err := s.Validate(); !errors.Is(err, errEmptyAdminAddr) {
+		t.Fatalf("expected empty admin addr error, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/sejta/nope/httpkit"
+)
+
+// Registrar — цель регистрации типизированных хендлеров: *Server или *Group.
+type Registrar interface {
+	fullPath(routePath string) string
+	handleAs(method, routePath string, h httpkit.Handler, name string, opts []RouteOption)
+	setBuildErr(err error)
+}
+
+// Validator реализуется типом запроса, если после привязки нужна проверка.
+// Ошибка возвращается клиенту как есть, поэтому используйте AppError.
+type Validator interface {
+	Validate() error
+}
+
+// Handle регистрирует типизированный хендлер.
+//
+// Req должен быть структурой. Поля заполняются по тегам:
+// path:"id" — параметр маршрута, query:"limit" — query-параметр,
+// header:"X-Foo" — заголовок, остальные экспортируемые поля — JSON-тело (строгий DecodeJSON).
+// Теги учитываются и во встроенных структурах; ключи тела с именами привязанных полей отбрасываются.
+// Ошибки преобразования возвращаются AppError 400 invalid_request с fields.
+// Если Req реализует Validator, Validate вызывается после привязки.
+//
+// Результат проходит через httpkit.Adapt: при Resp = any доступны httpkit.Created и httpkit.NoContent.
+func Handle[Req, Resp any](reg Registrar, method, routePath string, fn func(context.Context, Req) (Resp, error), opts ...RouteOption) {
+	if fn == nil {
+		reg.setBuildErr(errNilHandler)
+		return
+	}
+	reqType := reflect.TypeFor[Req]()
+	plan, err := newBindPlan(reqType, reg.fullPath(routePath))
+	if err != nil {
+		reg.setBuildErr(err)
+		return
+	}
+	h := func(ctx context.Context, r *http.Request) (any, error) {
+		var req Req
+		if err := plan.bind(r, reflect.ValueOf(&req).Elem()); err != nil {
+			return nil, err
+		}
+		if v, ok := any(&req).(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := fn(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	opts = append([]RouteOption{typedRoute(reqType, reflect.TypeFor[Resp](), &plan)}, opts...)
+	reg.handleAs(method, routePath, h, funcName(fn), opts)
+}
+
+func (s *Server) fullPath(routePath string) string {
+	return s.target().fullPath(routePath)
+}
+
+func (s *Server) handleAs(method, routePath string, h httpkit.Handler, name string, opts []RouteOption) {
+	s.handleNamed(s.target(), method, routePath, h, name, opts)
+}
+
+func (g *Group) fullPath(routePath string) string {
+	return g.target().fullPath(routePath)
+}
+
+func (g *Group) handleAs(method, routePath string, h httpkit.Handler, name string, opts []RouteOption) {
+	if _, err := joinPaths(g.prefix, routePath); err != nil {
+		g.s.setBuildErr(err)
+		return
+	}
+	g.s.handleNamed(g.target(), method, routePath, h, name, opts)
+}
+
+func (g *Group) setBuildErr(err error) {
+	g.s.setBuildErr(err)
+}
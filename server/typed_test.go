@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apperrors "github.com/sejta/nope/errors"
+	"github.com/sejta/nope/httpkit"
+)
+
+type updatePostRequest struct {
+	TenantID string   `path:"tid"`
+	ID       int64    `path:"id"`
+	Limit    *int     `query:"limit"`
+	Tags     []string `query:"tag"`
+	Trace    string   `header:"X-Trace"`
+	Title    string   `json:"title"`
+}
+
+func (r *updatePostRequest) Validate() error {
+	if r.Title == "forbidden" {
+		return apperrors.WithField(apperrors.E(http.StatusUnprocessableEntity, "validation_failed", "validation failed"), "title", "forbidden")
+	}
+	return nil
+}
+
+type updatePostResponse struct {
+	Tenant string   `json:"tenant"`
+	ID     int64    `json:"id"`
+	Limit  int      `json:"limit"`
+	Tags   []string `json:"tags"`
+	Trace  string   `json:"trace"`
+	Title  string   `json:"title"`
+}
+
+func updatePost(ctx context.Context, req updatePostRequest) (updatePostResponse, error) {
+	resp := updatePostResponse{Tenant: req.TenantID, ID: req.ID, Tags: req.Tags, Trace: req.Trace, Title: req.Title}
+	if req.Limit != nil {
+		resp.Limit = *req.Limit
+	}
+	return resp, nil
+}
+
+type postKey struct {
+	ID int64 `path:"id"`
+}
+
+type PostTrace struct {
+	Trace string `header:"X-Trace"`
+	Note  string `json:"note"`
+}
+
+type movePostRequest struct {
+	postKey
+	*PostTrace
+	Title string `json:"title"`
+}
+
+func typedServer(t *testing.T) http.Handler {
+	t.Helper()
+	s := New(":0")
+	tenants := s.Group("/tenants/:tid")
+	Handle(tenants, http.MethodPut, "/posts/:id", updatePost)
+	Handle(s, http.MethodPost, "/posts", func(ctx context.Context, req struct {
+		Title string `json:"title"`
+	}) (any, error) {
+		return httpkit.Created(map[string]string{"title": req.Title}), nil
+	})
+	Handle(s, http.MethodDelete, "/posts/:id", func(ctx context.Context, req struct {
+		ID int `path:"id"`
+	}) (any, error) {
+		return httpkit.NoContent(), nil
+	})
+	Handle(s, http.MethodPut, "/other/:id", func(ctx context.Context, req movePostRequest) (map[string]any, error) {
+		out := map[string]any{"id": req.ID, "title": req.Title}
+		if req.PostTrace != nil {
+			out["trace"], out["note"] = req.Trace, req.Note
+		}
+		return out, nil
+	})
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("handler build failed: %v", err)
+	}
+	return h
+}
+
+func TestTypedHandlerBinding(t *testing.T) {
+	h := typedServer(t)
+	req := httptest.NewRequest(http.MethodPut, "/tenants/acme/posts/42?limit=10&tag=a&tag=b", strings.NewReader(`{"title":"hello"}`))
+	req.Header.Set("X-Trace", "t-1")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var got updatePostResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	want := updatePostResponse{Tenant: "acme", ID: 42, Limit: 10, Tags: []string{"a", "b"}, Trace: "t-1", Title: "hello"}
+	if got.Tenant != want.Tenant || got.ID != want.ID || got.Limit != want.Limit || strings.Join(got.Tags, ",") != "a,b" || got.Trace != want.Trace || got.Title != want.Title {
+		t.Fatalf("got %+v want %+v", got, want)
+	}
+}
+
+func TestTypedHandlerBodyCannotSetBoundFields(t *testing.T) {
+	h := typedServer(t)
+	body := `{"title":"hello","TenantID":"evil","ID":"x","Limit":999,"Tags":["x"],"Trace":"spoofed"}`
+	req := httptest.NewRequest(http.MethodPut, "/tenants/acme/posts/42", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var got updatePostResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if got.Tenant != "acme" || got.ID != 42 || got.Limit != 0 || got.Tags != nil || got.Trace != "" || got.Title != "hello" {
+		t.Fatalf("bound fields must not come from body: %+v", got)
+	}
+
+	cases := []struct {
+		body   string
+		header string
+		want   string
+	}{
+		{body: `{"ID":9,"title":"t"}`, want: `{"id":5,"title":"t"}`},
+		{body: `{"ID":9,"Trace":"spoofed","note":"n","title":"t"}`, want: `{"id":5,"note":"n","title":"t","trace":""}`},
+		{body: `{"Trace":"spoofed"}`, header: "t-1", want: `{"id":5,"note":"","title":"","trace":"t-1"}`},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPut, "/other/5", strings.NewReader(tc.body))
+		if tc.header != "" {
+			req.Header.Set("X-Trace", tc.header)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: status=%d body=%s", tc.body, rr.Code, rr.Body.String())
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != tc.want {
+			t.Fatalf("%s: embedded bound fields must not come from body: got %s want %s", tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestTypedHandlerResults(t *testing.T) {
+	h := typedServer(t)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"title":"x"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status=%d want=%d", rr.Code, http.StatusCreated)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/posts/1", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("status=%d want=%d", rr.Code, http.StatusNoContent)
+	}
+}
+
+func TestTypedHandlerBindingErrors(t *testing.T) {
+	h := typedServer(t)
+	cases := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		code   string
+		fields []string
+	}{
+		{name: "bad path and query", path: "/tenants/acme/posts/x?limit=ten", body: `{}`, status: http.StatusBadRequest, code: CodeInvalidRequest, fields: []string{"id", "limit"}},
+		{name: "unknown body field", path: "/tenants/acme/posts/1", body: `{"nope":1}`, status: http.StatusBadRequest, code: "unexpected_field", fields: []string{"nope"}},
+		{name: "invalid json", path: "/tenants/acme/posts/1", body: `{`, status: http.StatusBadRequest, code: "invalid_json"},
+		{name: "validator", path: "/tenants/acme/posts/1", body: `{"title":"forbidden"}`, status: http.StatusUnprocessableEntity, code: "validation_failed", fields: []string{"title"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, tc.path, strings.NewReader(tc.body)))
+			if rr.Code != tc.status {
+				t.Fatalf("status=%d want=%d body=%s", rr.Code, tc.status, rr.Body.String())
+			}
+			var body struct {
+				Error struct {
+					Code   string            `json:"code"`
+					Fields map[string]string `json:"fields"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			if body.Error.Code != tc.code {
+				t.Fatalf("code=%q want=%q", body.Error.Code, tc.code)
+			}
+			for _, f := range tc.fields {
+				if body.Error.Fields[f] == "" {
+					t.Fatalf("fields=%v missing %q", body.Error.Fields, f)
+				}
+			}
+		})
+	}
+}
+
+func TestTypedHandlerBuildErrors(t *testing.T) {
+	s := New(":0")
+	Handle(s, http.MethodGet, "/a", func(ctx context.Context, req int) (any, error) { return nil, nil })
+	Handle(s, http.MethodGet, "/b", func(ctx context.Context, req struct {
+		ID int `path:"id"`
+	}) (any, error) {
+		return nil, nil
+	})
+	Handle(s, http.MethodGet, "/c/:id", func(ctx context.Context, req struct {
+		ID map[string]int `path:"id"`
+	}) (any, error) {
+		return nil, nil
+	})
+	Handle[struct{}, any](s, http.MethodGet, "/d", nil)
+	Handle(s, http.MethodGet, "/e/:id", func(ctx context.Context, req struct{ *postKey }) (any, error) {
+		return nil, nil
+	})
+
+	err := s.Validate()
+	if err == nil {
+		t.Fatalf("expected build error")
+	}
+	for _, want := range []error{errInvalidRequestType, errInvalidRoute, errNilHandler} {
+		if !errors.Is(err, want) {
+			t.Fatalf("error %q does not wrap %q", err, want)
+		}
+	}
+	for _, want := range []string{`path param "id" is not in /b`, "unsupported type map[string]int", "bind tags in unexported embedded pointer"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not contain %q", err, want)
+		}
+	}
+}